@@ -0,0 +1,230 @@
+package sqldb
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/binwen/sqldb/dialects"
+)
+
+const (
+	// 方言不支持命名锁时使用的锁表
+	lockTable = "sqldb_locks"
+	// 锁表中的锁默认租期，到期未续租的锁可被其他进程获取
+	defaultLockLease = 30 * time.Second
+	// 等待锁表中的锁时的重试间隔
+	lockRetryInterval = 50 * time.Millisecond
+)
+
+type LockOptions struct {
+	Lease time.Duration // 锁表的租期，默认 30 秒；数据库原生命名锁在连接断开前一直有效
+}
+
+// 命名锁，用于跨进程互斥；原生命名锁固定在获取锁的连接上，释放时归还连接
+type NamedLock struct {
+	db    *SqlDB
+	name  string
+	owner string
+	lease time.Duration
+	conn  *sql.Conn
+	mu    sync.Mutex
+	done  chan struct{}
+}
+
+// 获取命名锁，timeout 为 0 时只尝试一次，小于 0 时一直等待直到 ctx 取消，超时返回 ErrLockTimeout。
+// Postgres 使用 pg_advisory_lock，MySQL 使用 GET_LOCK，其他数据库使用带租期的锁表，如:
+//
+//	lock, err := db.Lock(ctx, "daily-report", 10*time.Second)
+//	if err != nil {
+//		return err
+//	}
+//	defer lock.Unlock(ctx)
+func (db *SqlDB) Lock(ctx context.Context, name string, timeout time.Duration, opts ...LockOptions) (*NamedLock, error) {
+	lock := &NamedLock{db: db, name: name, lease: defaultLockLease, done: make(chan struct{})}
+	if len(opts) > 0 && opts[0].Lease > 0 {
+		lock.lease = opts[0].Lease
+	}
+
+	if locker, ok := db.engine.Dialector.(dialects.AdvisoryLocker); ok {
+		conn, err := db.engine.Master().Conn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		acquired, err := locker.AcquireLock(ctx, conn, name, timeout)
+		if err != nil || !acquired {
+			conn.Close()
+			if err == nil {
+				err = ErrLockTimeout
+			}
+			return nil, err
+		}
+		lock.conn = conn
+		return lock, nil
+	}
+
+	if err := lock.acquireRow(ctx, timeout); err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+func (lock *NamedLock) master() *SqlDB {
+	return &SqlDB{engine: lock.db.engine, isMaster: true, logging: lock.db.logging}
+}
+
+// 在锁表中插入锁记录，已过期的锁会被清理
+func (lock *NamedLock) acquireRow(ctx context.Context, timeout time.Duration) error {
+	db := lock.master()
+	if _, err := db.ExecContext(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (name VARCHAR(255) NOT NULL PRIMARY KEY, owner VARCHAR(64) NOT NULL, expires_at BIGINT NOT NULL)",
+		lockTable,
+	)); err != nil {
+		return err
+	}
+
+	owner := make([]byte, 16)
+	if _, err := rand.Read(owner); err != nil {
+		return err
+	}
+	lock.owner = hex.EncodeToString(owner)
+
+	deadline := time.Now().Add(timeout)
+	for {
+		now := time.Now()
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE name = ? AND expires_at < ?", lockTable), lock.name, unixMilli(now)); err != nil {
+			return err
+		}
+		result, err := db.ExecContext(ctx, fmt.Sprintf(
+			"INSERT INTO %s (name, owner, expires_at) SELECT ?, ?, ? WHERE NOT EXISTS (SELECT 1 FROM %s WHERE name = ?)",
+			lockTable, lockTable,
+		), lock.name, lock.owner, unixMilli(now.Add(lock.lease)), lock.name)
+		if err != nil {
+			return err
+		}
+		if affected, err := result.RowsAffected(); err != nil || affected == 1 {
+			return err
+		}
+
+		wait := lockRetryInterval
+		if timeout >= 0 {
+			if wait = time.Until(deadline); wait <= 0 {
+				return ErrLockTimeout
+			}
+			if wait > lockRetryInterval {
+				wait = lockRetryInterval
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (lock *NamedLock) Name() string {
+	return lock.name
+}
+
+// 续租，锁表将锁的过期时间延长一个租期，原生命名锁检查连接是否存活；锁已丢失时返回 ErrLockLost
+func (lock *NamedLock) Renew(ctx context.Context) error {
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+	select {
+	case <-lock.done:
+		return ErrLockLost
+	default:
+	}
+
+	if lock.conn != nil {
+		if err := lock.conn.PingContext(ctx); err != nil {
+			return ErrLockLost
+		}
+		return nil
+	}
+
+	result, err := lock.master().ExecContext(ctx, fmt.Sprintf("UPDATE %s SET expires_at = ? WHERE name = ? AND owner = ?", lockTable),
+		unixMilli(time.Now().Add(lock.lease)), lock.name, lock.owner)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		if err == nil {
+			err = ErrLockLost
+		}
+		return err
+	}
+	return nil
+}
+
+// 在后台按 interval 定期续租，直到锁释放或 ctx 取消；续租失败时将错误发送到返回的 channel 并停止续租
+func (lock *NamedLock) KeepAlive(ctx context.Context, interval time.Duration) <-chan error {
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-lock.done:
+				return
+			case <-ticker.C:
+				if err := lock.Renew(ctx); err != nil {
+					select {
+					case <-lock.done:
+					default:
+						errs <- err
+					}
+					return
+				}
+			}
+		}
+	}()
+	return errs
+}
+
+// 释放锁，重复释放返回 ErrLockLost；原生命名锁释放失败时关闭其连接，由数据库释放锁
+func (lock *NamedLock) Unlock(ctx context.Context) error {
+	lock.mu.Lock()
+	defer lock.mu.Unlock()
+	select {
+	case <-lock.done:
+		return ErrLockLost
+	default:
+		close(lock.done)
+	}
+
+	if lock.conn != nil {
+		err := lock.db.engine.Dialector.(dialects.AdvisoryLocker).ReleaseLock(ctx, lock.conn, lock.name)
+		if err != nil {
+			// 释放失败时连接上可能仍持有锁，不能再复用该连接
+			discardConn(lock.conn)
+			return err
+		}
+		lock.conn.Close()
+		return nil
+	}
+
+	result, err := lock.master().ExecContext(ctx, fmt.Sprintf("DELETE FROM %s WHERE name = ? AND owner = ?", lockTable), lock.name, lock.owner)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil || affected == 0 {
+		if err == nil {
+			err = ErrLockLost
+		}
+		return err
+	}
+	return nil
+}
+
+func unixMilli(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
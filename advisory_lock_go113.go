@@ -0,0 +1,11 @@
+//go:build !go1.14
+// +build !go1.14
+
+package sqldb
+
+import "database/sql"
+
+// Go 1.14 之前无法丢弃指定的连接，只能关闭后归还连接池，连接上的锁在连接被回收时释放
+func discardConn(conn *sql.Conn) {
+	conn.Close()
+}
@@ -0,0 +1,17 @@
+//go:build go1.14
+// +build go1.14
+
+package sqldb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+)
+
+// 丢弃连接而不归还连接池，数据库在连接断开时释放连接上的锁
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(interface{}) error {
+		return driver.ErrBadConn
+	})
+	conn.Close()
+}
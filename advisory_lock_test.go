@@ -0,0 +1,134 @@
+package sqldb_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/tests"
+)
+
+func TestAdvisoryLock(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		ctx := context.Background()
+		lock, err := tests.DBEngine.Lock(ctx, "cron:report", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := tests.DBEngine.Lock(ctx, "cron:report", 0); err != sqldb.ErrLockTimeout {
+			t.Errorf("held lock should not be acquired again, got `%v`", err)
+		}
+		start := time.Now()
+		if _, err := tests.DBEngine.Lock(ctx, "cron:report", 200*time.Millisecond); err != sqldb.ErrLockTimeout {
+			t.Errorf("held lock should time out, got `%v`", err)
+		} else if time.Since(start) < 200*time.Millisecond {
+			t.Errorf("lock should wait until timeout, waited %v", time.Since(start))
+		}
+
+		other, err := tests.DBEngine.Lock(ctx, "cron:cleanup", 0)
+		if err != nil {
+			t.Fatalf("different lock name should be acquired, got `%v`", err)
+		}
+		if err := other.Unlock(ctx); err != nil {
+			t.Error(err)
+		}
+
+		if err := lock.Renew(ctx); err != nil {
+			t.Errorf("held lock should be renewed, got `%v`", err)
+		}
+
+		acquired := make(chan *sqldb.NamedLock)
+		go func() {
+			waiter, err := tests.DBEngine.Lock(ctx, "cron:report", 5*time.Second)
+			if err != nil {
+				t.Error(err)
+			}
+			acquired <- waiter
+		}()
+		time.Sleep(100 * time.Millisecond)
+		if err := lock.Unlock(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if err := lock.Unlock(ctx); err != sqldb.ErrLockLost {
+			t.Errorf("released lock should not be released again, got `%v`", err)
+		}
+		if err := lock.Renew(ctx); err != sqldb.ErrLockLost {
+			t.Errorf("released lock should not be renewed, got `%v`", err)
+		}
+
+		waiter := <-acquired
+		if waiter == nil {
+			t.Fatal("waiting lock should be acquired after release")
+		}
+		if err := waiter.Unlock(ctx); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestAdvisoryLockLease(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		if tests.DBEngine.DriverName() != "sqlite3" {
+			t.Skip("lease only applies to the lock table")
+		}
+
+		ctx := context.Background()
+		lock, err := tests.DBEngine.Lock(ctx, "cron:lease", 0, sqldb.LockOptions{Lease: 100 * time.Millisecond})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		keepCtx, stop := context.WithCancel(ctx)
+		errs := lock.KeepAlive(keepCtx, 30*time.Millisecond)
+		time.Sleep(200 * time.Millisecond)
+		if _, err := tests.DBEngine.Lock(ctx, "cron:lease", 0); err != sqldb.ErrLockTimeout {
+			t.Errorf("renewed lock should still be held, got `%v`", err)
+		}
+		stop()
+		if err := <-errs; err != nil {
+			t.Errorf("keep alive should stop without error, got `%v`", err)
+		}
+
+		time.Sleep(200 * time.Millisecond)
+		expired, err := tests.DBEngine.Lock(ctx, "cron:lease", 0)
+		if err != nil {
+			t.Fatalf("expired lock should be acquired by others, got `%v`", err)
+		}
+		if err := lock.Renew(ctx); err != sqldb.ErrLockLost {
+			t.Errorf("expired lock should be lost, got `%v`", err)
+		}
+		if err := expired.Unlock(ctx); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestAdvisoryLockReleaseFailure(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		ctx := context.Background()
+		lock, err := tests.DBEngine.Lock(ctx, "cron:release", 0, sqldb.LockOptions{Lease: 100 * time.Millisecond})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+		if err := lock.Unlock(cancelled); err == nil {
+			t.Error("unlock with cancelled context should return error")
+		}
+		if err := lock.Unlock(ctx); err != sqldb.ErrLockLost {
+			t.Errorf("failed release should not be retried, got `%v`", err)
+		}
+
+		// 原生命名锁随连接关闭释放，锁表中的锁在租期到期后释放
+		other, err := tests.DBEngine.Lock(ctx, "cron:release", 2*time.Second)
+		if err != nil {
+			t.Fatalf("lock should be acquired after failed release, got `%v`", err)
+		}
+		if err := other.Unlock(ctx); err != nil {
+			t.Error(err)
+		}
+	})
+}
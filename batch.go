@@ -0,0 +1,93 @@
+package sqldb
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/binwen/sqldb/clause"
+)
+
+type BatchOptions struct {
+	Key  string // 分批使用的键，需唯一且可排序，默认为主键(无法获取时为 id)
+	InTx bool   // 每批数据在独立的事务中处理
+}
+
+func (session *Session) batchKey() string {
+	if session.dryRun != nil {
+		return "id"
+	}
+	if pkColumnNames := session.statement.Dialector.PKColumnNames(session.db, session.statement.Tables[0].Name); len(pkColumnNames) == 1 {
+		return pkColumnNames[0]
+	}
+	return "id"
+}
+
+// 按键分批(keyset)查询，每批数据扫描到 dest 后调用 fn，fn 返回错误时停止；
+// dest 必须为 slice 指针，每批开始前会被清空
+func (session *Session) FindInBatches(dest interface{}, size int, fn func(db *SqlDB, batch int) error, opts ...BatchOptions) error {
+	session = session.getInstance()
+	defer session.Clear()
+	if session.Error != nil {
+		return session.Error
+	}
+
+	if size <= 0 {
+		return fmt.Errorf("batch size must be greater than 0, got %d", size)
+	}
+
+	destRefValue := reflect.ValueOf(dest)
+	if destRefValue.Kind() != reflect.Ptr || destRefValue.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("batch destination must be a pointer to slice, got %T", dest)
+	}
+	rows := destRefValue.Elem()
+
+	var opt BatchOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Key == "" {
+		opt.Key = session.batchKey()
+	}
+
+	delete(session.statement.Clauses, "ORDER BY")
+	delete(session.statement.Clauses, "LIMIT")
+	session.statement.groupWhere()
+
+	var lastKey interface{}
+	for batch := 1; ; batch++ {
+		query := session.Clone()
+		if batch > 1 {
+			query = query.AddClause(clause.Gt{Column: parseColumn(opt.Key), Value: lastKey})
+		}
+
+		query.statement.AddClause(clause.OrderBy{Columns: []clause.OrderByColumn{{Column: parseColumn(opt.Key)}}})
+
+		rows.Set(reflect.MakeSlice(rows.Type(), 0, size))
+		if err := query.Limit(size).Find(dest); err != nil {
+			return err
+		}
+
+		count := rows.Len()
+		if count == 0 {
+			return nil
+		}
+
+		var err error
+		if opt.InTx {
+			err = session.db.TxContext(session.ctx, func(db *SqlDB) error {
+				return fn(db, batch)
+			})
+		} else {
+			err = fn(session.db, batch)
+		}
+		if err != nil || count < size {
+			return err
+		}
+
+		values, err := cursorValues(rows.Index(count-1), []string{opt.Key})
+		if err != nil {
+			return err
+		}
+		lastKey = values[0]
+	}
+}
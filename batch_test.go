@@ -0,0 +1,97 @@
+package sqldb_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/tests"
+)
+
+func TestFindInBatches(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3, 4, 5, 6, 7)
+		var (
+			users   []tests.SimpleAuthUser
+			ids     []int
+			batches int
+		)
+		if err := tests.DBEngine.Table("auth_user").Select("id, username").Where("id <> ?", 4).FindInBatches(&users, 2, func(db *sqldb.SqlDB, batch int) error {
+			batches = batch
+			for _, user := range users {
+				ids = append(ids, user.Id)
+			}
+			return nil
+		}); err != nil {
+			t.Error(err)
+		}
+		if batches != 3 || len(ids) != 6 || ids[5] != 7 {
+			t.Errorf("should process ids `[1 2 3 5 6 7]` in `3` batches, got `%v` in `%v` batches", ids, batches)
+		}
+
+		ids, batches = nil, 0
+		base := tests.DBEngine.Table("auth_user").Select("id, username").Where("id <> ?", 4).Immutable()
+		if err := base.FindInBatches(&users, 2, func(db *sqldb.SqlDB, batch int) error {
+			if batches = batch; batch > 3 {
+				return errors.New("batches should not repeat")
+			}
+			for _, user := range users {
+				ids = append(ids, user.Id)
+			}
+			return nil
+		}); err != nil {
+			t.Error(err)
+		}
+		if batches != 3 || len(ids) != 6 || ids[5] != 7 {
+			t.Errorf("immutable session should process ids `[1 2 3 5 6 7]` in `3` batches, got `%v` in `%v` batches", ids, batches)
+		}
+
+		ids, batches = nil, 0
+		if err := tests.DBEngine.Table("auth_user").Where("id = ?", 1).Or("id > ?", 4).FindInBatches(&users, 2, func(db *sqldb.SqlDB, batch int) error {
+			if batches = batch; batch > 2 {
+				return errors.New("batches should not repeat")
+			}
+			for _, user := range users {
+				ids = append(ids, user.Id)
+			}
+			return nil
+		}); err != nil {
+			t.Error(err)
+		}
+		if batches != 2 || len(ids) != 4 || ids[0] != 1 || ids[3] != 7 {
+			t.Errorf("batch key should apply to every OR branch and process ids `[1 5 6 7]`, got `%v` in `%v` batches", ids, batches)
+		}
+
+		stopErr := errors.New("stop")
+		batches = 0
+		var maps []map[string]interface{}
+		if err := tests.DBEngine.Table("auth_user").FindInBatches(&maps, 3, func(db *sqldb.SqlDB, batch int) error {
+			batches++
+			return stopErr
+		}); err != stopErr {
+			t.Errorf("batch error should be returned, got `%v`", err)
+		}
+		if batches != 1 {
+			t.Errorf("should stop after first batch, got `%v` batches", batches)
+		}
+
+		if err := tests.DBEngine.Table("auth_user").FindInBatches(&users, 4, func(db *sqldb.SqlDB, batch int) error {
+			for _, user := range users {
+				if _, err := db.Table("auth_user").Where("id = ?", user.Id).Update("age", 30); err != nil {
+					return err
+				}
+			}
+			if batch == 2 {
+				return stopErr
+			}
+			return nil
+		}, sqldb.BatchOptions{Key: "id", InTx: true}); err != stopErr {
+			t.Errorf("batch error should be returned, got `%v`", err)
+		}
+		if count, err := tests.DBEngine.Table("auth_user").Where("age = ?", 30).Count(); err != nil {
+			t.Error(err)
+		} else if count != 4 {
+			t.Errorf("failed batch should be rolled back, updated count should be `4`, got `%v`", count)
+		}
+	})
+}
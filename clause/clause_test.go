@@ -13,10 +13,6 @@ import (
 type DummyDialector struct {
 }
 
-func (dia *DummyDialector) SetQueryer(queryer dialects.Queryer) {
-
-}
-
 func (dia *DummyDialector) LastInsertIDReversed() bool {
 	return false
 }
@@ -35,7 +31,7 @@ func (dia *DummyDialector) QuoteTo(writer clause.Writer, str string) {
 	writer.WriteByte('`')
 }
 
-func (dia *DummyDialector) PKColumnNames(table string) (columnNames []string) {
+func (dia *DummyDialector) PKColumnNames(queryer dialects.Queryer, table string) (columnNames []string) {
 	return
 }
 
@@ -1,7 +1,9 @@
 package clause
 
 import (
+	"database/sql"
 	"database/sql/driver"
+	"fmt"
 	"reflect"
 )
 
@@ -210,3 +212,115 @@ func (in IN) NegationBuild(builder Builder) {
 		builder.WriteByte(')')
 	}
 }
+
+// 命名参数表达式，SQL 中使用 @name 引用参数，Vars 可以为 sql.NamedArg 或 map[string]interface{}；
+// 参数为 slice 时展开为 IN 列表。未提供的参数原样输出，构建前可通过 Check 校验
+type NamedExpr struct {
+	SQL  string
+	Vars []interface{}
+}
+
+func (expr NamedExpr) namedMap() map[string]interface{} {
+	namedMap := make(map[string]interface{}, len(expr.Vars))
+	for _, v := range expr.Vars {
+		switch value := v.(type) {
+		case sql.NamedArg:
+			namedMap[value.Name] = value.Value
+		case map[string]interface{}:
+			for k, v := range value {
+				namedMap[k] = v
+			}
+		}
+	}
+	return namedMap
+}
+
+// 依次处理 SQL 中的文本及 @name 参数，引号内的内容及 @@ 系统变量作为文本处理；
+// param 返回 false 时 @name 也作为文本处理
+func (expr NamedExpr) walk(text func(s string), param func(name string) bool) {
+	var (
+		quote    byte
+		sqlBytes = []byte(expr.SQL)
+	)
+	for i := 0; i < len(sqlBytes); i++ {
+		v := sqlBytes[i]
+		switch {
+		case quote != 0:
+			if v == quote {
+				quote = 0
+			}
+		case v == '\'' || v == '"' || v == '`':
+			quote = v
+		case v == '@' && i+1 < len(sqlBytes) && sqlBytes[i+1] == '@':
+			text("@@")
+			i++
+			continue
+		case v == '@':
+			j := i + 1
+			for j < len(sqlBytes) && isNameChar(sqlBytes[j]) {
+				j++
+			}
+			if j > i+1 && param(string(sqlBytes[i+1:j])) {
+				i = j - 1
+				continue
+			}
+		}
+		text(string(v))
+	}
+}
+
+// 校验 SQL 中引用的命名参数是否都已提供
+func (expr NamedExpr) Check() error {
+	var (
+		namedMap = expr.namedMap()
+		missing  string
+	)
+	expr.walk(func(string) {}, func(name string) bool {
+		if _, ok := namedMap[name]; !ok && missing == "" {
+			missing = name
+		}
+		return true
+	})
+	if missing != "" {
+		return fmt.Errorf("named argument @%s not found", missing)
+	}
+	return nil
+}
+
+func (expr NamedExpr) Build(builder Builder) {
+	var (
+		namedMap = expr.namedMap()
+		lastByte byte
+	)
+	expr.walk(func(s string) {
+		builder.WriteString(s)
+		if c := s[len(s)-1]; c != ' ' && c != '\t' && c != '\n' {
+			lastByte = c
+		}
+	}, func(name string) bool {
+		value, ok := namedMap[name]
+		if !ok {
+			return false
+		}
+		if _, isBytes := value.([]byte); lastByte == '(' && !isBytes && isSlice(value) {
+			rv := reflect.Indirect(reflect.ValueOf(value))
+			if rv.Len() == 0 {
+				builder.WriteString("NULL")
+			}
+			for k := 0; k < rv.Len(); k++ {
+				if k > 0 {
+					builder.WriteByte(',')
+				}
+				builder.AddSQLVar(builder, rv.Index(k).Interface())
+			}
+		} else {
+			builder.AddSQLVar(builder, value)
+		}
+		lastByte = '?'
+		return true
+	})
+}
+
+func isNameChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,71 @@
+package clause_test
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/binwen/sqldb/clause"
+)
+
+func TestNamedExpr(t *testing.T) {
+	results := []struct {
+		Clauses []clause.IClause
+		Result  string
+		Vars    []interface{}
+	}{
+		{
+			[]clause.IClause{
+				clause.Select{},
+				clause.From{},
+				clause.Where{Exprs: []clause.Expression{
+					clause.NamedExpr{SQL: "name = @name AND age > @age", Vars: []interface{}{sql.Named("name", "jinzhu"), sql.Named("age", 18)}},
+				}},
+			},
+			"SELECT * FROM `user` WHERE name = ? AND age > ?",
+			[]interface{}{"jinzhu", 18},
+		},
+		{
+			[]clause.IClause{
+				clause.Select{},
+				clause.From{},
+				clause.Where{Exprs: []clause.Expression{
+					clause.NamedExpr{SQL: "id IN (@ids) AND role IN @roles AND name <> @name", Vars: []interface{}{
+						map[string]interface{}{"ids": []int{1, 2}, "roles": []string{"admin"}, "name": "jinzhu"},
+					}},
+				}},
+			},
+			"SELECT * FROM `user` WHERE id IN (?,?) AND role IN (?) AND name <> ?",
+			[]interface{}{1, 2, "admin", "jinzhu"},
+		},
+		{
+			[]clause.IClause{
+				clause.Select{},
+				clause.From{},
+				clause.Where{Exprs: []clause.Expression{
+					clause.NamedExpr{SQL: "email LIKE '%@name' AND @@autocommit = 1 AND name = @name AND x = @missing", Vars: []interface{}{sql.Named("name", "jinzhu")}},
+				}},
+			},
+			"SELECT * FROM `user` WHERE email LIKE '%@name' AND @@autocommit = 1 AND name = ? AND x = @missing",
+			[]interface{}{"jinzhu"},
+		},
+	}
+
+	for idx, result := range results {
+		t.Run(fmt.Sprintf("case #%v", idx), func(t *testing.T) {
+			checkBuildClauses(t, result.Clauses, result.Result, result.Vars)
+		})
+	}
+}
+
+func TestNamedExprCheck(t *testing.T) {
+	expr := clause.NamedExpr{SQL: "email LIKE '%@email' AND @@autocommit = 1 AND name = @name", Vars: []interface{}{sql.Named("name", "jinzhu")}}
+	if err := expr.Check(); err != nil {
+		t.Errorf("quoted names and system variables should not be checked, got `%v`", err)
+	}
+
+	expr.SQL += " AND x = @missing"
+	if err := expr.Check(); err == nil || err.Error() != "named argument @missing not found" {
+		t.Errorf("missing named argument should return error, got `%v`", err)
+	}
+}
@@ -77,3 +77,32 @@ func (values Values) MergeClause(clause *Clause) {
 	}
 	clause.Expression = values
 }
+
+// INSERT INTO ... SELECT，占用 VALUES 的位置，Query 为查询语句
+type InsertSelect struct {
+	Columns []Column
+	Query   Expression
+}
+
+func (InsertSelect) Name() string {
+	return "VALUES"
+}
+
+func (insert InsertSelect) Build(builder Builder) {
+	if len(insert.Columns) > 0 {
+		builder.WriteByte('(')
+		for idx, column := range insert.Columns {
+			if idx > 0 {
+				builder.WriteByte(',')
+			}
+			builder.WriteQuoted(column)
+		}
+		builder.WriteString(") ")
+	}
+	insert.Query.Build(builder)
+}
+
+func (insert InsertSelect) MergeClause(clause *Clause) {
+	clause.Name = ""
+	clause.Expression = insert
+}
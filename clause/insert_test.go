@@ -24,6 +24,17 @@ func TestInsert(t *testing.T) {
 			"INSERT INTO `user` (`name`,`age`) VALUES (?,?),(?,?)",
 			[]interface{}{"bin", 18, "wen", 1},
 		},
+		{
+			[]clause.IClause{
+				clause.Insert{},
+				clause.InsertSelect{
+					Columns: []clause.Column{{Name: "name"}, {Name: "age"}},
+					Query:   clause.Expr{SQL: "SELECT name,age FROM users WHERE age > ?", Vars: []interface{}{18}},
+				},
+			},
+			"INSERT INTO `user` (`name`,`age`) SELECT name,age FROM users WHERE age > ?",
+			[]interface{}{18},
+		},
 		{
 			[]clause.IClause{
 				clause.Insert{Modifier: "LOW_PRIORITY"},
@@ -4,6 +4,7 @@ type Lock struct {
 	Strength string
 	Table    Table
 	Options  string
+	Raw      bool // Strength 为完整的加锁语句，不加 FOR，如 MySQL 5.7 的 LOCK IN SHARE MODE
 }
 
 type For struct {
@@ -20,7 +21,9 @@ func (f For) Build(builder Builder) {
 			builder.WriteByte(' ')
 		}
 
-		builder.WriteString("FOR ")
+		if !locking.Raw {
+			builder.WriteString("FOR ")
+		}
 		builder.WriteString(locking.Strength)
 		if locking.Table.Name != "" {
 			builder.WriteString(" OF ")
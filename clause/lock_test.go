@@ -33,6 +33,14 @@ func TestFor(t *testing.T) {
 			"SELECT * FROM `user` FOR UPDATE FOR SHARE OF `user`",
 			nil,
 		},
+		{
+			[]clause.IClause{
+				clause.Select{},
+				clause.From{},
+				clause.For{Locks: []clause.Lock{{Strength: "LOCK IN SHARE MODE", Options: "NOWAIT", Raw: true}}}},
+			"SELECT * FROM `user` LOCK IN SHARE MODE NOWAIT",
+			nil,
+		},
 		{
 			[]clause.IClause{
 				clause.Select{},
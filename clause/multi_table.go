@@ -0,0 +1,201 @@
+package clause
+
+import "regexp"
+
+// 多表修改、删除的语法
+type JoinStyle int
+
+const (
+	// EXISTS 子查询: UPDATE a SET ... WHERE EXISTS (SELECT 1 FROM b WHERE ...)
+	SubqueryJoinStyle JoinStyle = iota
+	// MySQL: UPDATE a JOIN b ON ... SET ... / DELETE a FROM a JOIN b ON ...
+	InlineJoinStyle
+	// Postgres: UPDATE a SET ... FROM b WHERE ... / DELETE FROM a USING b WHERE ...
+	FromJoinStyle
+)
+
+// 多表修改，完整构建 UPDATE 语句；除 InlineJoinStyle 外，Joins 必须为带 ON 条件的内连接
+type UpdateJoin struct {
+	Style JoinStyle
+	Table Table
+	Joins []Join
+	Set   Set
+	Where Where
+}
+
+func (UpdateJoin) Name() string {
+	return "UPDATE"
+}
+
+func (update UpdateJoin) Build(builder Builder) {
+	builder.WriteString("UPDATE ")
+	builder.WriteQuoted(update.Table)
+
+	if update.Style == InlineJoinStyle {
+		for _, join := range update.Joins {
+			builder.WriteByte(' ')
+			join.Build(builder)
+		}
+		builder.WriteString(" SET ")
+		update.Set.Build(builder)
+		buildJoinWhere(builder, nil, update.Where)
+		return
+	}
+
+	builder.WriteString(" SET ")
+	for idx, assignment := range update.Set.Assignments {
+		if idx > 0 {
+			builder.WriteByte(',')
+		}
+		assignment.Column.Table = ""
+		builder.WriteQuoted(assignment.Column)
+		builder.WriteByte('=')
+		// EXISTS 子查询中的连接表在 SET 中不可见，引用连接表的值改为关联子查询
+		if update.Style == SubqueryJoinStyle && referencesJoins(assignment.Value, update.Joins) {
+			builder.WriteString("(SELECT ")
+			builder.AddSQLVar(builder, assignment.Value)
+			builder.WriteString(" FROM ")
+			buildJoinTables(builder, update.Joins)
+			buildJoinWhere(builder, update.Joins, update.Where)
+			builder.WriteByte(')')
+		} else {
+			builder.AddSQLVar(builder, assignment.Value)
+		}
+	}
+
+	if update.Style == FromJoinStyle {
+		builder.WriteString(" FROM ")
+		buildJoinTables(builder, update.Joins)
+		buildJoinWhere(builder, update.Joins, update.Where)
+	} else {
+		buildJoinExists(builder, update.Joins, update.Where)
+	}
+}
+
+func (update UpdateJoin) MergeClause(clause *Clause) {
+	clause.Name = ""
+	clause.Expression = update
+}
+
+// 多表删除，完整构建 DELETE 语句，只删除 Table 中的数据
+type DeleteJoin struct {
+	Style JoinStyle
+	Table Table
+	Joins []Join
+	Where Where
+}
+
+func (DeleteJoin) Name() string {
+	return "DELETE"
+}
+
+func (delete DeleteJoin) Build(builder Builder) {
+	switch delete.Style {
+	case InlineJoinStyle:
+		builder.WriteString("DELETE ")
+		if delete.Table.Alias != "" {
+			builder.WriteQuoted(delete.Table.Alias)
+		} else {
+			builder.WriteQuoted(delete.Table.Name)
+		}
+		builder.WriteString(" FROM ")
+		builder.WriteQuoted(delete.Table)
+		for _, join := range delete.Joins {
+			builder.WriteByte(' ')
+			join.Build(builder)
+		}
+		buildJoinWhere(builder, nil, delete.Where)
+	case FromJoinStyle:
+		builder.WriteString("DELETE FROM ")
+		builder.WriteQuoted(delete.Table)
+		builder.WriteString(" USING ")
+		buildJoinTables(builder, delete.Joins)
+		buildJoinWhere(builder, delete.Joins, delete.Where)
+	default:
+		builder.WriteString("DELETE FROM ")
+		builder.WriteQuoted(delete.Table)
+		buildJoinExists(builder, delete.Joins, delete.Where)
+	}
+}
+
+func (delete DeleteJoin) MergeClause(clause *Clause) {
+	clause.Name = ""
+	clause.Expression = delete
+}
+
+func buildJoinTables(builder Builder, joins []Join) {
+	for idx, join := range joins {
+		if idx > 0 {
+			builder.WriteByte(',')
+		}
+		builder.WriteQuoted(join.Table)
+	}
+}
+
+// 写入连接条件与查询条件，查询条件整体加括号以保持 OR 的优先级
+func buildJoinWhere(builder Builder, joins []Join, where Where) {
+	var written bool
+	for _, join := range joins {
+		if len(join.ON.Exprs) == 0 {
+			continue
+		}
+		if written {
+			builder.WriteString(" AND ")
+		} else {
+			builder.WriteString(" WHERE ")
+			written = true
+		}
+		builder.WriteByte('(')
+		join.ON.Build(builder)
+		builder.WriteByte(')')
+	}
+
+	if len(where.Exprs) == 0 {
+		return
+	}
+	if written {
+		builder.WriteString(" AND (")
+		Where{Exprs: append([]Expression(nil), where.Exprs...)}.Build(builder)
+		builder.WriteByte(')')
+	} else {
+		builder.WriteString(" WHERE ")
+		Where{Exprs: append([]Expression(nil), where.Exprs...)}.Build(builder)
+	}
+}
+
+func buildJoinExists(builder Builder, joins []Join, where Where) {
+	builder.WriteString(" WHERE EXISTS (SELECT 1 FROM ")
+	buildJoinTables(builder, joins)
+	buildJoinWhere(builder, joins, where)
+	builder.WriteByte(')')
+}
+
+// 字段或表达式是否引用了连接表(按表名或别名加 . 判断)
+func referencesJoins(value interface{}, joins []Join) bool {
+	var sql string
+	switch v := value.(type) {
+	case Column:
+		if v.Table == "" {
+			return false
+		}
+		sql = v.Table + "."
+	case Expr:
+		sql = v.SQL
+	case *Expr:
+		if v == nil {
+			return false
+		}
+		sql = v.SQL
+	default:
+		return false
+	}
+
+	for _, join := range joins {
+		for _, name := range []string{join.Table.Name, join.Table.Alias} {
+			if name != "" && regexp.MustCompile("(?:^|[^\\w.])[`\"]?"+regexp.QuoteMeta(name)+"[`\"]?\\.").MatchString(sql) {
+				return true
+			}
+		}
+	}
+	return false
+}
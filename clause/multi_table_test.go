@@ -0,0 +1,68 @@
+package clause_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/binwen/sqldb/clause"
+)
+
+func TestUpdateJoin(t *testing.T) {
+	joins := []clause.Join{{Table: clause.Table{Name: "groups", Alias: "g"}, ON: clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "g.user_id = user.id"}}}}}
+	set := clause.Set{Assignments: []clause.Assignment{{clause.Column{Table: "user", Name: "age"}, 1}}}
+	where := clause.Where{Exprs: []clause.Expression{clause.EQ{Column: "g.name", Value: "admin"}, clause.Or(clause.EQ{Column: "g.id", Value: 2})}}
+
+	results := []struct {
+		Clauses []clause.IClause
+		Result  string
+		Vars    []interface{}
+	}{
+		{
+			[]clause.IClause{clause.UpdateJoin{Style: clause.InlineJoinStyle, Table: clause.Table{Name: "user"}, Joins: []clause.Join{{Type: clause.InnerJoin, Table: clause.Table{Name: "groups", Alias: "g"}, Using: []string{"id"}}}, Set: set, Where: where}},
+			"UPDATE `user` INNER JOIN `groups` AS `g` USING (`id`) SET `user`.`age`=? WHERE `g.name` = ? OR `g.id` = ?",
+			[]interface{}{1, "admin", 2},
+		},
+		{
+			[]clause.IClause{clause.UpdateJoin{Style: clause.FromJoinStyle, Table: clause.Table{Name: "user"}, Joins: joins, Set: set, Where: where}},
+			"UPDATE `user` SET `age`=? FROM `groups` AS `g` WHERE (g.user_id = user.id) AND (`g.name` = ? OR `g.id` = ?)",
+			[]interface{}{1, "admin", 2},
+		},
+		{
+			[]clause.IClause{clause.UpdateJoin{Style: clause.SubqueryJoinStyle, Table: clause.Table{Name: "user"}, Joins: joins, Set: set}},
+			"UPDATE `user` SET `age`=? WHERE EXISTS (SELECT 1 FROM `groups` AS `g` WHERE (g.user_id = user.id))",
+			[]interface{}{1},
+		},
+		{
+			[]clause.IClause{clause.UpdateJoin{Style: clause.SubqueryJoinStyle, Table: clause.Table{Name: "user"}, Joins: joins, Set: clause.Set{Assignments: []clause.Assignment{
+				{clause.Column{Name: "group_name"}, clause.Column{Table: "g", Name: "name"}},
+				{clause.Column{Name: "age"}, clause.Expr{SQL: "age + ?", Vars: []interface{}{1}}},
+				{clause.Column{Name: "score"}, clause.Expr{SQL: "`groups`.score * ?", Vars: []interface{}{2}}},
+			}}, Where: where}},
+			"UPDATE `user` SET `group_name`=(SELECT `g`.`name` FROM `groups` AS `g` WHERE (g.user_id = user.id) AND (`g.name` = ? OR `g.id` = ?)),`age`=age + ?," +
+				"`score`=(SELECT `groups`.score * ? FROM `groups` AS `g` WHERE (g.user_id = user.id) AND (`g.name` = ? OR `g.id` = ?)) " +
+				"WHERE EXISTS (SELECT 1 FROM `groups` AS `g` WHERE (g.user_id = user.id) AND (`g.name` = ? OR `g.id` = ?))",
+			[]interface{}{"admin", 2, 1, 2, "admin", 2, "admin", 2},
+		},
+		{
+			[]clause.IClause{clause.DeleteJoin{Style: clause.InlineJoinStyle, Table: clause.Table{Name: "user", Alias: "u"}, Joins: []clause.Join{{Expression: clause.Expr{SQL: "JOIN groups g ON g.user_id = u.id"}}}, Where: where}},
+			"DELETE `u` FROM `user` AS `u` JOIN groups g ON g.user_id = u.id WHERE `g.name` = ? OR `g.id` = ?",
+			[]interface{}{"admin", 2},
+		},
+		{
+			[]clause.IClause{clause.DeleteJoin{Style: clause.FromJoinStyle, Table: clause.Table{Name: "user"}, Joins: joins, Where: where}},
+			"DELETE FROM `user` USING `groups` AS `g` WHERE (g.user_id = user.id) AND (`g.name` = ? OR `g.id` = ?)",
+			[]interface{}{"admin", 2},
+		},
+		{
+			[]clause.IClause{clause.DeleteJoin{Style: clause.SubqueryJoinStyle, Table: clause.Table{Name: "user"}, Joins: joins, Where: where}},
+			"DELETE FROM `user` WHERE EXISTS (SELECT 1 FROM `groups` AS `g` WHERE (g.user_id = user.id) AND (`g.name` = ? OR `g.id` = ?))",
+			[]interface{}{"admin", 2},
+		},
+	}
+
+	for idx, result := range results {
+		t.Run(fmt.Sprintf("case #%v", idx), func(t *testing.T) {
+			checkBuildClauses(t, result.Clauses, result.Result, result.Vars)
+		})
+	}
+}
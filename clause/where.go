@@ -91,6 +91,17 @@ func (or OrConditions) Build(builder Builder) {
 	}
 }
 
+// 将一组条件作为整体，条件之间按 WHERE 的规则以 AND/OR 连接，构建时始终加括号
+type GroupConditions struct {
+	Exprs []Expression
+}
+
+func (group GroupConditions) Build(builder Builder) {
+	builder.WriteByte('(')
+	Where{Exprs: group.Exprs}.Build(builder)
+	builder.WriteByte(')')
+}
+
 func Not(exprs ...Expression) Expression {
 	if len(exprs) == 0 {
 		return nil
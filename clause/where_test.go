@@ -114,6 +114,23 @@ func TestWhere(t *testing.T) {
 			"SELECT * FROM `user` WHERE (`id` <> ? AND `age` <= ?) OR `name` <> ? AND (`score` <= ? OR `name` LIKE ?)",
 			[]interface{}{"1", 18, "jinzhu", 100, "%linus%"},
 		},
+		{
+			[]clause.IClause{
+				clause.Select{},
+				clause.From{},
+				clause.Where{
+					Exprs: []clause.Expression{
+						clause.GroupConditions{Exprs: []clause.Expression{
+							clause.EQ{Column: "id", Value: "1"},
+							clause.Or(clause.NEQ{Column: "name", Value: "jinzhu"}),
+						}},
+						clause.EQ{Column: "tenant_id", Value: 7},
+					},
+				},
+			},
+			"SELECT * FROM `user` WHERE (`id` = ? OR `name` <> ?) AND `tenant_id` = ?",
+			[]interface{}{"1", "jinzhu", 7},
+		},
 	}
 
 	for idx, result := range results {
@@ -0,0 +1,162 @@
+package sqldb
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/binwen/sqldb/clause"
+	"github.com/binwen/sqldb/dialects"
+)
+
+// 回退到批量 INSERT 时默认每批的最大行数
+const defaultCopyBatchSize = 1000
+
+// 逐行产生导入数据的迭代器
+type CopySource interface {
+	Columns() []string
+	Next() bool
+	Values() ([]interface{}, error)
+	Err() error
+}
+
+type CopyOptions struct {
+	BatchSize   int  // 回退到批量 INSERT 时每批的行数，默认根据方言的占位符上限计算
+	LocalInfile bool // MySQL 使用 LOAD DATA LOCAL INFILE 导入，需服务端开启 local_infile
+}
+
+type valuesSource struct {
+	values clause.Values
+	index  int
+}
+
+func (s *valuesSource) Columns() []string {
+	columns := make([]string, len(s.values.Columns))
+	for idx, column := range s.values.Columns {
+		columns[idx] = column.Name
+	}
+	return columns
+}
+
+func (s *valuesSource) Next() bool {
+	s.index++
+	return s.index <= len(s.values.Values)
+}
+
+func (s *valuesSource) Values() ([]interface{}, error) {
+	return s.values.Values[s.index-1], nil
+}
+
+func (s *valuesSource) Err() error {
+	return nil
+}
+
+func nextFunc(source CopySource) func() ([]interface{}, error) {
+	return func() ([]interface{}, error) {
+		if !source.Next() {
+			if err := source.Err(); err != nil {
+				return nil, err
+			}
+			return nil, io.EOF
+		}
+		return source.Values()
+	}
+}
+
+// 快速批量导入，返回导入的行数；rows 可以为 map 或 struct 组成的 slice，或 CopySource 迭代器。
+// Postgres 使用 COPY ... FROM STDIN，MySQL 在开启 LocalInfile 时使用 LOAD DATA LOCAL INFILE，
+// 其他情况回退到分批的多行 INSERT；所有数据在同一个事务中导入
+func (session *Session) CopyFrom(rows interface{}, opts ...CopyOptions) (count int64, err error) {
+	session = session.getInstance()
+	defer session.Clear()
+	if session.Error != nil {
+		return 0, session.Error
+	}
+
+	source, ok := rows.(CopySource)
+	if !ok {
+		direct := reflect.Indirect(reflect.ValueOf(rows))
+		if kind := direct.Kind(); kind != reflect.Slice && kind != reflect.Array {
+			return 0, fmt.Errorf("copy from the given value must `slice` or `array` structure of `map` or `struct` or CopySource, got %v", kind)
+		}
+		if direct.Len() == 0 {
+			return 0, nil
+		}
+		source = &valuesSource{values: convertCreateValues(direct, rows)}
+	}
+
+	var opt CopyOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	copyFrom := func(db *SqlDB) (err error) {
+		next := nextFunc(source)
+		if copier, ok := session.statement.Dialector.(dialects.Copier); ok && session.dryRun == nil {
+			count, err = copier.CopyFrom(
+				session.ctx, db.tx.Tx, session.statement.Tables[0].Name, source.Columns(), next,
+				dialects.CopyOptions{LocalInfile: opt.LocalInfile},
+			)
+			if err != dialects.ErrCopyNotSupported {
+				return err
+			}
+		}
+		count, err = session.copyByInsert(db, source.Columns(), next, opt.BatchSize)
+		return err
+	}
+
+	if session.db.tx != nil || session.dryRun != nil {
+		err = copyFrom(session.db)
+	} else {
+		err = session.db.TxContext(session.ctx, copyFrom)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// 分批使用多行 INSERT 导入
+func (session *Session) copyByInsert(db *SqlDB, columnNames []string, next func() ([]interface{}, error), batchSize int) (count int64, err error) {
+	columns := make([]clause.Column, len(columnNames))
+	for idx, name := range columnNames {
+		columns[idx] = clause.Column{Name: name}
+	}
+	if batchSize <= 0 {
+		if batchSize = session.bulkBatchSize(len(columns), nil); batchSize > defaultCopyBatchSize {
+			batchSize = defaultCopyBatchSize
+		}
+	}
+
+	values := clause.Values{Columns: columns}
+	flush := func() error {
+		if len(values.Values) == 0 {
+			return nil
+		}
+		batch := session.Clone()
+		batch.db = db
+		if result := batch.insert(values); result.err != nil && result.err != ErrDryRun {
+			return result.err
+		}
+		count += int64(len(values.Values))
+		values.Values = nil
+		return nil
+	}
+
+	for {
+		row, err := next()
+		if err == io.EOF {
+			return count, flush()
+		}
+		if err != nil {
+			return count, err
+		}
+
+		values.Values = append(values.Values, row)
+		if len(values.Values) >= batchSize {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package sqldb_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/tests"
+)
+
+type userSource struct {
+	count int
+	index int
+	err   error
+}
+
+func (s *userSource) Columns() []string {
+	return []string{"username", "is_superuser", "age", "date_joined"}
+}
+
+func (s *userSource) Next() bool {
+	if s.index >= s.count {
+		return false
+	}
+	s.index++
+	return true
+}
+
+func (s *userSource) Values() ([]interface{}, error) {
+	if s.err != nil && s.index == s.count {
+		return nil, s.err
+	}
+	return []interface{}{"copy", false, s.index, time.Now()}, nil
+}
+
+func (s *userSource) Err() error {
+	return nil
+}
+
+func TestCopyFrom(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		users := make([]tests.AuthUser, 500)
+		for i := range users {
+			users[i] = tests.AuthUser{UserName: "slice", Age: i, ModelTime: tests.ModelTime{DateJoined: time.Now()}}
+		}
+		if count, err := tests.DBEngine.Table("auth_user").CopyFrom(users); err != nil {
+			t.Error(err)
+		} else if count != 500 {
+			t.Errorf("should copy `500` rows, got `%v`", count)
+		}
+		if count, err := tests.DBEngine.Table("auth_user").Where("username = ? AND age = ?", "slice", 0).Count(); err != nil || count != 1 {
+			t.Errorf("zero age should be copied as `0`, got `%v` `%v`", count, err)
+		}
+
+		if count, err := tests.DBEngine.Table("auth_user").CopyFrom(&userSource{count: 1200}, sqldb.CopyOptions{BatchSize: 100}); err != nil {
+			t.Error(err)
+		} else if count != 1200 {
+			t.Errorf("should copy `1200` rows, got `%v`", count)
+		}
+		if count, _ := tests.DBEngine.Table("auth_user").Where("username = ?", "copy").Count(); count != 1200 {
+			t.Errorf("should find `1200` copied rows, got `%v`", count)
+		}
+
+		sourceErr := errors.New("source error")
+		if _, err := tests.DBEngine.Table("auth_user").CopyFrom(&userSource{count: 300, err: sourceErr}, sqldb.CopyOptions{BatchSize: 100}); err != sourceErr {
+			t.Errorf("source error should be returned, got `%v`", err)
+		}
+		if count, _ := tests.DBEngine.Table("auth_user").Count(); count != 1700 {
+			t.Errorf("failed copy should be rolled back, got `%v` rows", count)
+		}
+
+		statements, err := tests.DBEngine.Table("auth_user").ToSQL(func(s *sqldb.Session) error {
+			_, err := s.CopyFrom(&userSource{count: 5}, sqldb.CopyOptions{BatchSize: 2})
+			return err
+		})
+		if err != nil {
+			t.Error(err)
+		} else if len(statements) != 3 {
+			t.Errorf("dry run should record `3` insert statements, got `%v`", len(statements))
+		}
+	})
+}
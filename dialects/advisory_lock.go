@@ -0,0 +1,14 @@
+package dialects
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// 支持命名锁(咨询锁)的方言实现该接口，加锁与释放在同一个连接上执行，连接断开时锁自动释放；
+// timeout 为 0 时只尝试一次，小于 0 时一直等待直到 ctx 取消；未获得锁时返回 false
+type AdvisoryLocker interface {
+	AcquireLock(ctx context.Context, conn *sql.Conn, name string, timeout time.Duration) (bool, error)
+	ReleaseLock(ctx context.Context, conn *sql.Conn, name string) error
+}
@@ -0,0 +1,19 @@
+package dialects
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+// 方言不支持(或未启用)快速导入时返回，调用方应回退到批量 INSERT
+var ErrCopyNotSupported = errors.New("copy is not supported")
+
+type CopyOptions struct {
+	LocalInfile bool // MySQL 使用 LOAD DATA LOCAL INFILE 导入，需服务端开启 local_infile
+}
+
+// 支持快速导入的方言实现该接口；next 依次返回每行数据，数据读完时返回 io.EOF
+type Copier interface {
+	CopyFrom(ctx context.Context, tx *sql.Tx, table string, columns []string, next func() ([]interface{}, error), opts CopyOptions) (int64, error)
+}
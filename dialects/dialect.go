@@ -1,6 +1,10 @@
 package dialects
 
 import (
+	"errors"
+	"strconv"
+	"strings"
+
 	"github.com/jmoiron/sqlx"
 
 	"github.com/binwen/sqldb/clause"
@@ -8,19 +12,52 @@ import (
 
 var dialectMapping = map[string]Dialector{}
 
+// 数据库(或当前版本)不支持的行锁，LockTranslator 返回的错误包装该错误
+var ErrUnsupportedLock = errors.New("unsupported row lock")
+
+// 方言查询数据库信息使用的连接，方言在会话间共享，因此由调用方传入，为 nil 时(如 dry run)不查询数据库
 type Queryer interface {
 	QueryRow(query string, args ...interface{}) *sqlx.Row
 }
 
 type Dialector interface {
-	SetQueryer(queryer Queryer)
 	QuoteTo(clause.Writer, string)
 	BindVarTo(writer clause.Writer, varIndex int, v interface{})
-	PKColumnNames(table string) []string
+	PKColumnNames(queryer Queryer, table string) []string
 	LastInsertIDReversed() bool
 	WithReturning() bool
 }
 
+// 方言可实现该接口声明单条语句允许的最大占位符数量，批量插入时据此自动分批
+type PlaceholderLimiter interface {
+	MaxPlaceholders() int
+}
+
+// 方言可实现该接口声明单条语句允许的最大字节数(如 MySQL 的 max_allowed_packet)，批量插入时据此进一步分批，返回 0 表示不限制
+type PacketLimiter interface {
+	MaxPacketSize(queryer Queryer) int
+}
+
+// 方言可实现该接口指定多表修改、删除的语法，未实现时使用 EXISTS 子查询
+type JoinStyler interface {
+	JoinStyle() clause.JoinStyle
+}
+
+// 方言实现该接口声明 UPDATE、DELETE 是否支持 RETURNING，未实现时与 WithReturning 一致
+type ReturningSupporter interface {
+	UpdateReturning(queryer Queryer) bool
+}
+
+// 方言实现该接口声明 INSERT ... SELECT 带 ON CONFLICT 时 SELECT 必须有 WHERE 子句，以免 ON 被解析为连接条件
+type InsertSelectWherer interface {
+	InsertSelectNeedsWhere() bool
+}
+
+// 方言实现该接口转换行锁子句，返回 nil 表示忽略该锁，不支持时返回包装 ErrUnsupportedLock 的错误
+type LockTranslator interface {
+	TranslateLock(queryer Queryer, lock clause.Lock) (*clause.Lock, error)
+}
+
 func RegisterDialector(name string, dialect Dialector) {
 	dialectMapping[name] = dialect
 }
@@ -29,3 +66,27 @@ func GetDialector(name string) (dialect Dialector, ok bool) {
 	dialect, ok = dialectMapping[name]
 	return
 }
+
+// 解析服务端版本号的主、次版本，如 "8.0.32"、"10.6.12-MariaDB"、"13.4 (Debian 13.4-1)"
+func ParseVersion(version string) (major, minor int) {
+	parts := strings.SplitN(strings.TrimLeft(version, "v "), ".", 3)
+	digits := func(s string) int {
+		end := 0
+		for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+			end++
+		}
+		n, _ := strconv.Atoi(s[:end])
+		return n
+	}
+	major = digits(parts[0])
+	if len(parts) > 1 {
+		minor = digits(parts[1])
+	}
+	return major, minor
+}
+
+// 版本是否不低于 major.minor
+func VersionAtLeast(version string, major, minor int) bool {
+	m, n := ParseVersion(version)
+	return m > major || m == major && n >= minor
+}
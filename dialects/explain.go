@@ -0,0 +1,35 @@
+package dialects
+
+// 执行计划节点，各数据库的执行计划统一解析为该结构
+type PlanNode struct {
+	Operation string      // 操作类型，如 Seq Scan、SCAN、Table scan
+	Table     string      // 访问的表
+	Index     string      // 使用的索引
+	Detail    string      // 原始描述
+	FullScan  bool        // 是否为全表扫描
+	Cost      float64     // 估算成本
+	Rows      float64     // 估算(或 ANALYZE 实际)行数
+	Time      float64     // ANALYZE 实际耗时(毫秒)
+	Children  []*PlanNode // 子节点
+}
+
+// 返回计划树中所有全表扫描的节点
+func (node *PlanNode) FullScans() (nodes []*PlanNode) {
+	if node == nil {
+		return
+	}
+
+	if node.FullScan {
+		nodes = append(nodes, node)
+	}
+	for _, child := range node.Children {
+		nodes = append(nodes, child.FullScans()...)
+	}
+	return
+}
+
+// 支持执行计划的方言实现该接口
+type Explainer interface {
+	ExplainSQL(query string, analyze bool) string
+	ParseExplain(rows []map[string]interface{}, analyze bool) (*PlanNode, error)
+}
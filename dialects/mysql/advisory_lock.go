@@ -0,0 +1,36 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// 使用 GET_LOCK 加锁，锁名最长 64 个字符
+func (dia *Dialector) AcquireLock(ctx context.Context, conn *sql.Conn, name string, timeout time.Duration) (bool, error) {
+	seconds := timeout.Seconds()
+	if timeout < 0 {
+		seconds = -1
+	}
+
+	var acquired sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", name, seconds).Scan(&acquired); err != nil {
+		return false, err
+	}
+	if !acquired.Valid {
+		return false, fmt.Errorf("failed to acquire lock `%s`", name)
+	}
+	return acquired.Int64 == 1, nil
+}
+
+func (dia *Dialector) ReleaseLock(ctx context.Context, conn *sql.Conn, name string) error {
+	var released sql.NullInt64
+	if err := conn.QueryRowContext(ctx, "SELECT RELEASE_LOCK(?)", name).Scan(&released); err != nil {
+		return err
+	}
+	if released.Int64 != 1 {
+		return fmt.Errorf("lock `%s` is not held by this connection", name)
+	}
+	return nil
+}
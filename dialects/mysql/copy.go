@@ -0,0 +1,124 @@
+package mysql
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/binwen/sqldb/dialects"
+)
+
+var readerSeq uint64
+
+// 使用 LOAD DATA LOCAL INFILE 导入数据，未启用 LocalInfile 时返回 ErrCopyNotSupported
+func (dia *Dialector) CopyFrom(ctx context.Context, tx *sql.Tx, table string, columns []string, next func() ([]interface{}, error), opts dialects.CopyOptions) (int64, error) {
+	if !opts.LocalInfile {
+		return 0, dialects.ErrCopyNotSupported
+	}
+
+	name := fmt.Sprintf("sqldb_copy_%d", atomic.AddUint64(&readerSeq, 1))
+	reader, writer := io.Pipe()
+	mysql.RegisterReaderHandler(name, func() io.Reader { return reader })
+	defer mysql.DeregisterReaderHandler(name)
+	defer reader.Close()
+
+	go func() {
+		writer.CloseWithError(writeLoadData(writer, next))
+	}()
+
+	var query strings.Builder
+	query.WriteString("LOAD DATA LOCAL INFILE 'Reader::")
+	query.WriteString(name)
+	query.WriteString("' INTO TABLE ")
+	dia.QuoteTo(&query, table)
+	query.WriteString(" (")
+	for idx, column := range columns {
+		if idx > 0 {
+			query.WriteByte(',')
+		}
+		dia.QuoteTo(&query, column)
+	}
+	query.WriteByte(')')
+
+	result, err := tx.ExecContext(ctx, query.String())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// 按 LOAD DATA 的默认格式写入: 字段以 \t 分隔，行以 \n 结束，NULL 写为 \N
+func writeLoadData(w io.Writer, next func() ([]interface{}, error)) error {
+	buf := bufio.NewWriter(w)
+	for {
+		values, err := next()
+		if err == io.EOF {
+			return buf.Flush()
+		}
+		if err != nil {
+			return err
+		}
+
+		for idx, value := range values {
+			if idx > 0 {
+				buf.WriteByte('\t')
+			}
+			if err := writeLoadDataValue(buf, value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('\n')
+	}
+}
+
+func writeLoadDataValue(buf *bufio.Writer, value interface{}) error {
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return err
+		}
+		value = v
+	}
+
+	var text string
+	switch v := value.(type) {
+	case nil:
+		_, err := buf.WriteString(`\N`)
+		return err
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	case time.Time:
+		text = v.Format("2006-01-02 15:04:05.999999")
+	case bool:
+		if v {
+			text = "1"
+		} else {
+			text = "0"
+		}
+	default:
+		text = fmt.Sprint(v)
+	}
+
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; c {
+		case '\\', '\t', '\n':
+			buf.WriteByte('\\')
+			buf.WriteByte(c)
+		case 0:
+			buf.WriteString(`\0`)
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package mysql
+
+import (
+	"bytes"
+	"database/sql"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestWriteLoadData(t *testing.T) {
+	rows := [][]interface{}{
+		{1, "a\tb\\c", nil, true},
+		{2, "line\nbreak", sql.NullString{String: "x", Valid: true}, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{0, "", int64(0), false},
+	}
+	var idx int
+	next := func() ([]interface{}, error) {
+		if idx >= len(rows) {
+			return nil, io.EOF
+		}
+		idx++
+		return rows[idx-1], nil
+	}
+
+	var buf bytes.Buffer
+	if err := writeLoadData(&buf, next); err != nil {
+		t.Fatal(err)
+	}
+	expected := "1\ta\\\tb\\\\c\t\\N\t1\n2\tline\\\nbreak\tx\t2020-01-02 03:04:05\n0\t\t0\t0\n"
+	if buf.String() != expected {
+		t.Errorf("load data should be %q, got %q", expected, buf.String())
+	}
+}
@@ -0,0 +1,40 @@
+package mysql
+
+import "testing"
+
+func TestParseExplain(t *testing.T) {
+	dia := &Dialector{}
+	plan, err := dia.ParseExplain([]map[string]interface{}{{"EXPLAIN": `{
+  "query_block": {
+    "select_id": 1,
+    "cost_info": {"query_cost": "2.75"},
+    "nested_loop": [
+      {"table": {"table_name": "u", "access_type": "ALL", "rows_examined_per_scan": 5, "attached_condition": "(u.age > 10)"}},
+      {"table": {"table_name": "ug", "access_type": "ref", "key": "idx_user", "rows_examined_per_scan": 1}}
+    ]
+  }
+}`}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Cost != 2.75 || len(plan.Children) != 2 {
+		t.Fatalf("plan should have cost `2.75` and `2` children, got `%+v`", plan)
+	}
+	if scans := plan.FullScans(); len(scans) != 1 || scans[0].Table != "u" || scans[0].Rows != 5 {
+		t.Errorf("plan should flag full table scan on `u`, got `%+v`", scans)
+	}
+
+	plan, err = dia.ParseExplain([]map[string]interface{}{{"EXPLAIN": `-> Filter: (auth_user.age > 10)  (cost=0.75 rows=1) (actual time=0.031..0.045 rows=2 loops=1)
+    -> Table scan on auth_user  (cost=0.75 rows=5) (actual time=0.028..0.039 rows=5 loops=1)
+`}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Children) != 1 || len(plan.Children[0].Children) != 1 {
+		t.Fatalf("analyze plan should be nested two levels, got `%+v`", plan)
+	}
+	scan := plan.Children[0].Children[0]
+	if !scan.FullScan || scan.Table != "auth_user" || scan.Rows != 5 || scan.Time != 0.039 {
+		t.Errorf("analyze plan should flag full table scan on `auth_user`, got `%+v`", scan)
+	}
+}
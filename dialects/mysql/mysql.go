@@ -1,6 +1,10 @@
 package mysql
 
 import (
+	"fmt"
+	"strings"
+	"sync"
+
 	_ "github.com/go-sql-driver/mysql"
 
 	"github.com/binwen/sqldb/clause"
@@ -8,17 +12,19 @@ import (
 )
 
 type Dialector struct {
-	queryer              dialects.Queryer
 	lastInsertIDReversed bool
 	withReturning        bool
+	serverMu             sync.Mutex
+	server               *serverInfo
 }
 
-func init() {
-	dialects.RegisterDialector("mysql", &Dialector{})
+type serverInfo struct {
+	version   string
+	maxPacket int
 }
 
-func (dia *Dialector) SetQueryer(queryer dialects.Queryer) {
-	dia.queryer = queryer
+func init() {
+	dialects.RegisterDialector("mysql", &Dialector{})
 }
 
 func (dia *Dialector) LastInsertIDReversed() bool {
@@ -29,6 +35,68 @@ func (dia *Dialector) WithReturning() bool {
 	return dia.withReturning
 }
 
+// 预处理语句最多 65535 个占位符
+func (dia *Dialector) MaxPlaceholders() int {
+	return 65535
+}
+
+// 单个数据包不能超过服务端的 max_allowed_packet，未能查询时不限制
+func (dia *Dialector) MaxPacketSize(queryer dialects.Queryer) int {
+	info, _ := dia.serverInfo(queryer)
+	return info.maxPacket
+}
+
+// 查询服务端版本及 max_allowed_packet，查询成功后缓存
+func (dia *Dialector) serverInfo(queryer dialects.Queryer) (serverInfo, bool) {
+	dia.serverMu.Lock()
+	defer dia.serverMu.Unlock()
+	if dia.server == nil {
+		var info serverInfo
+		if queryer == nil || queryer.QueryRow("SELECT VERSION(), @@max_allowed_packet").Scan(&info.version, &info.maxPacket) != nil {
+			return serverInfo{}, false
+		}
+		dia.server = &info
+	}
+	return *dia.server, true
+}
+
+// MySQL 8.0 起支持 FOR SHARE、OF、NOWAIT、SKIP LOCKED；之前的版本及 MariaDB 共享锁使用 LOCK IN SHARE MODE，
+// 不支持 OF，MariaDB 10.3 起支持 NOWAIT，10.6 起支持 SKIP LOCKED；未能查询版本时按 MySQL 8.0 处理
+func (dia *Dialector) TranslateLock(queryer dialects.Queryer, lock clause.Lock) (*clause.Lock, error) {
+	info, ok := dia.serverInfo(queryer)
+	mariadb := strings.Contains(strings.ToLower(info.version), "mariadb")
+	modern := !ok || !mariadb && dialects.VersionAtLeast(info.version, 8, 0)
+
+	if lock.Strength != "UPDATE" && lock.Strength != "SHARE" {
+		return nil, fmt.Errorf("%w: mysql does not support `FOR %s`", dialects.ErrUnsupportedLock, lock.Strength)
+	}
+	if lock.Table.Name != "" && !modern {
+		return nil, fmt.Errorf("%w: mysql %s does not support locking rows of table `%s`", dialects.ErrUnsupportedLock, info.version, lock.Table.Name)
+	}
+	switch lock.Options {
+	case "":
+	case "NOWAIT":
+		if !modern && !(mariadb && dialects.VersionAtLeast(info.version, 10, 3)) {
+			return nil, fmt.Errorf("%w: mysql %s does not support NOWAIT", dialects.ErrUnsupportedLock, info.version)
+		}
+	case "SKIP LOCKED":
+		if !modern && !(mariadb && dialects.VersionAtLeast(info.version, 10, 6)) {
+			return nil, fmt.Errorf("%w: mysql %s does not support SKIP LOCKED", dialects.ErrUnsupportedLock, info.version)
+		}
+	default:
+		return nil, fmt.Errorf("%w: mysql does not support row lock option `%s`", dialects.ErrUnsupportedLock, lock.Options)
+	}
+
+	if lock.Strength == "SHARE" && !modern {
+		lock.Strength, lock.Raw = "LOCK IN SHARE MODE", true
+	}
+	return &lock, nil
+}
+
+func (dia *Dialector) JoinStyle() clause.JoinStyle {
+	return clause.InlineJoinStyle
+}
+
 func (dia *Dialector) BindVarTo(writer clause.Writer, varIndex int, v interface{}) {
 	writer.WriteByte('?')
 }
@@ -39,6 +107,6 @@ func (dia *Dialector) QuoteTo(writer clause.Writer, str string) {
 	writer.WriteByte('`')
 }
 
-func (dia *Dialector) PKColumnNames(table string) (columnNames []string) {
+func (dia *Dialector) PKColumnNames(queryer dialects.Queryer, table string) (columnNames []string) {
 	return
 }
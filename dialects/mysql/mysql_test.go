@@ -0,0 +1,40 @@
+package mysql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/binwen/sqldb/clause"
+	"github.com/binwen/sqldb/dialects"
+)
+
+func TestTranslateLock(t *testing.T) {
+	results := []struct {
+		Version  string
+		Lock     clause.Lock
+		Expected *clause.Lock
+	}{
+		{"8.0.32", clause.Lock{Strength: "SHARE", Table: clause.Table{Name: "user"}, Options: "SKIP LOCKED"}, &clause.Lock{Strength: "SHARE", Table: clause.Table{Name: "user"}, Options: "SKIP LOCKED"}},
+		{"5.7.31-log", clause.Lock{Strength: "UPDATE"}, &clause.Lock{Strength: "UPDATE"}},
+		{"5.7.31-log", clause.Lock{Strength: "SHARE"}, &clause.Lock{Strength: "LOCK IN SHARE MODE", Raw: true}},
+		{"5.7.31-log", clause.Lock{Strength: "UPDATE", Options: "NOWAIT"}, nil},
+		{"5.7.31-log", clause.Lock{Strength: "UPDATE", Options: "SKIP LOCKED"}, nil},
+		{"5.7.31-log", clause.Lock{Strength: "UPDATE", Table: clause.Table{Name: "user"}}, nil},
+		{"10.3.25-MariaDB", clause.Lock{Strength: "SHARE", Options: "NOWAIT"}, &clause.Lock{Strength: "LOCK IN SHARE MODE", Options: "NOWAIT", Raw: true}},
+		{"10.3.25-MariaDB", clause.Lock{Strength: "UPDATE", Options: "SKIP LOCKED"}, nil},
+		{"10.6.12-MariaDB", clause.Lock{Strength: "UPDATE", Options: "SKIP LOCKED"}, &clause.Lock{Strength: "UPDATE", Options: "SKIP LOCKED"}},
+		{"8.0.32", clause.Lock{Strength: "NO KEY UPDATE"}, nil},
+	}
+
+	for _, result := range results {
+		dia := &Dialector{server: &serverInfo{version: result.Version}}
+		lock, err := dia.TranslateLock(nil, result.Lock)
+		if result.Expected == nil {
+			if !errors.Is(err, dialects.ErrUnsupportedLock) {
+				t.Errorf("%v %+v should be unsupported, got `%+v` `%v`", result.Version, result.Lock, lock, err)
+			}
+		} else if err != nil || lock == nil || *lock != *result.Expected {
+			t.Errorf("%v %+v should be translated to `%+v`, got `%+v` `%v`", result.Version, result.Lock, result.Expected, lock, err)
+		}
+	}
+}
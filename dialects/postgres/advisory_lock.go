@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"time"
+)
+
+// 等待锁时重试 pg_try_advisory_lock 的间隔
+const lockRetryInterval = 50 * time.Millisecond
+
+// 锁名经 FNV-1a 哈希为 bigint 作为咨询锁的 key
+func lockKey(name string) int64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(name))
+	return int64(hash.Sum64())
+}
+
+// 一直等待时使用 pg_advisory_lock，否则在超时前重试 pg_try_advisory_lock
+func (dia *Dialector) AcquireLock(ctx context.Context, conn *sql.Conn, name string, timeout time.Duration) (bool, error) {
+	key := lockKey(name)
+	if timeout < 0 {
+		_, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", key)
+		return err == nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		var acquired bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&acquired); err != nil || acquired {
+			return acquired, err
+		}
+
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return false, nil
+		}
+		if wait > lockRetryInterval {
+			wait = lockRetryInterval
+		}
+		select {
+		case <-ctx.Done():
+			return false, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (dia *Dialector) ReleaseLock(ctx context.Context, conn *sql.Conn, name string) error {
+	var released bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_advisory_unlock($1)", lockKey(name)).Scan(&released); err != nil {
+		return err
+	}
+	if !released {
+		return fmt.Errorf("lock `%s` is not held by this connection", name)
+	}
+	return nil
+}
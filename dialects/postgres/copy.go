@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"io"
+
+	"github.com/lib/pq"
+
+	"github.com/binwen/sqldb/dialects"
+)
+
+// 使用 COPY ... FROM STDIN 导入数据
+func (dia *Dialector) CopyFrom(ctx context.Context, tx *sql.Tx, table string, columns []string, next func() ([]interface{}, error), opts dialects.CopyOptions) (count int64, err error) {
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	for {
+		values, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, err
+		}
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/binwen/sqldb/dialects"
+)
+
+func (dia *Dialector) ExplainSQL(query string, analyze bool) string {
+	if analyze {
+		return "EXPLAIN (ANALYZE, FORMAT JSON) " + query
+	}
+	return "EXPLAIN (FORMAT JSON) " + query
+}
+
+type planJSON struct {
+	NodeType        string     `json:"Node Type"`
+	RelationName    string     `json:"Relation Name"`
+	IndexName       string     `json:"Index Name"`
+	Filter          string     `json:"Filter"`
+	TotalCost       float64    `json:"Total Cost"`
+	PlanRows        float64    `json:"Plan Rows"`
+	ActualRows      float64    `json:"Actual Rows"`
+	ActualTotalTime float64    `json:"Actual Total Time"`
+	Plans           []planJSON `json:"Plans"`
+}
+
+func (dia *Dialector) ParseExplain(rows []map[string]interface{}, analyze bool) (*dialects.PlanNode, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("empty postgres query plan")
+	}
+
+	var plans []struct {
+		Plan planJSON `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(fmt.Sprint(rows[0]["QUERY PLAN"])), &plans); err != nil {
+		return nil, err
+	}
+	if len(plans) == 0 {
+		return nil, fmt.Errorf("empty postgres query plan")
+	}
+
+	return convertPlan(plans[0].Plan, analyze), nil
+}
+
+func convertPlan(plan planJSON, analyze bool) *dialects.PlanNode {
+	node := &dialects.PlanNode{
+		Operation: plan.NodeType,
+		Table:     plan.RelationName,
+		Index:     plan.IndexName,
+		Detail:    plan.Filter,
+		FullScan:  plan.NodeType == "Seq Scan",
+		Cost:      plan.TotalCost,
+		Rows:      plan.PlanRows,
+	}
+	if analyze {
+		node.Rows = plan.ActualRows
+		node.Time = plan.ActualTotalTime
+	}
+
+	for _, child := range plan.Plans {
+		node.Children = append(node.Children, convertPlan(child, analyze))
+	}
+	return node
+}
@@ -0,0 +1,26 @@
+package postgres
+
+import "testing"
+
+func TestParseExplain(t *testing.T) {
+	dia := &Dialector{}
+	plan, err := dia.ParseExplain([]map[string]interface{}{{"QUERY PLAN": `[{"Plan": {
+  "Node Type": "Hash Join", "Total Cost": 35.5, "Plan Rows": 10, "Actual Rows": 3, "Actual Total Time": 0.12,
+  "Plans": [
+    {"Node Type": "Seq Scan", "Relation Name": "auth_user", "Total Cost": 20.1, "Plan Rows": 100, "Filter": "(age > 10)"},
+    {"Node Type": "Index Scan", "Relation Name": "auth_group", "Index Name": "auth_group_pkey", "Total Cost": 8.2, "Plan Rows": 1}
+  ]
+}}]`}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Operation != "Hash Join" || plan.Rows != 3 || plan.Time != 0.12 || len(plan.Children) != 2 {
+		t.Fatalf("plan root not expected, got `%+v`", plan)
+	}
+	if scans := plan.FullScans(); len(scans) != 1 || scans[0].Table != "auth_user" {
+		t.Errorf("plan should flag full table scan on `auth_user`, got `%+v`", scans)
+	}
+	if plan.Children[1].Index != "auth_group_pkey" {
+		t.Errorf("index name should be `auth_group_pkey`, got `%v`", plan.Children[1].Index)
+	}
+}
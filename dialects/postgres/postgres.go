@@ -1,8 +1,10 @@
 package postgres
 
 import (
+	"fmt"
 	"strconv"
 	"strings"
+	"sync"
 
 	_ "github.com/lib/pq"
 
@@ -11,19 +13,16 @@ import (
 )
 
 type Dialector struct {
-	queryer              dialects.Queryer
 	lastInsertIDReversed bool
 	withReturning        bool
+	versionMu            sync.Mutex
+	version              string
 }
 
 func init() {
 	dialects.RegisterDialector("postgres", &Dialector{withReturning: true})
 }
 
-func (dia *Dialector) SetQueryer(queryer dialects.Queryer) {
-	dia.queryer = queryer
-}
-
 func (dia *Dialector) LastInsertIDReversed() bool {
 	return dia.lastInsertIDReversed
 }
@@ -32,6 +31,33 @@ func (dia *Dialector) WithReturning() bool {
 	return dia.withReturning
 }
 
+// 协议中参数个数为 16 位整数
+func (dia *Dialector) MaxPlaceholders() int {
+	return 65535
+}
+
+// SKIP LOCKED 需要 PostgreSQL 9.5 及以上版本，未能查询版本时不限制
+func (dia *Dialector) TranslateLock(queryer dialects.Queryer, lock clause.Lock) (*clause.Lock, error) {
+	if version := dia.serverVersion(queryer); lock.Options == "SKIP LOCKED" && version != "" && !dialects.VersionAtLeast(version, 9, 5) {
+		return nil, fmt.Errorf("%w: postgres %s does not support SKIP LOCKED", dialects.ErrUnsupportedLock, version)
+	}
+	return &lock, nil
+}
+
+// 查询服务端版本，查询成功后缓存
+func (dia *Dialector) serverVersion(queryer dialects.Queryer) string {
+	dia.versionMu.Lock()
+	defer dia.versionMu.Unlock()
+	if dia.version == "" && queryer != nil {
+		_ = queryer.QueryRow("SHOW server_version").Scan(&dia.version)
+	}
+	return dia.version
+}
+
+func (dia *Dialector) JoinStyle() clause.JoinStyle {
+	return clause.FromJoinStyle
+}
+
 func (dia *Dialector) BindVarTo(writer clause.Writer, varIndex int, v interface{}) {
 	writer.WriteByte('$')
 	writer.WriteString(strconv.Itoa(varIndex))
@@ -43,10 +69,13 @@ func (dia *Dialector) QuoteTo(writer clause.Writer, str string) {
 	writer.WriteByte('"')
 }
 
-func (dia *Dialector) PKColumnNames(table string) (columnNames []string) {
+func (dia *Dialector) PKColumnNames(queryer dialects.Queryer, table string) (columnNames []string) {
+	if queryer == nil {
+		return
+	}
 	sql := "SELECT indexdef FROM pg_indexes WHERE tablename=$1 and indexname in ($2,'primary') limit 1"
 	var indexdef string
-	_ = dia.queryer.QueryRow(sql, table, table+"_pkey").Scan(&indexdef)
+	_ = queryer.QueryRow(sql, table, table+"_pkey").Scan(&indexdef)
 	if indexdef == "" {
 		return
 	}
@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/binwen/sqldb/clause"
+	"github.com/binwen/sqldb/dialects"
+)
+
+func TestTranslateLock(t *testing.T) {
+	lock := clause.Lock{Strength: "NO KEY UPDATE", Options: "SKIP LOCKED"}
+	if _, err := (&Dialector{version: "9.4.26"}).TranslateLock(nil, lock); !errors.Is(err, dialects.ErrUnsupportedLock) {
+		t.Errorf("skip locked should be unsupported before 9.5, got `%v`", err)
+	}
+	if translated, err := (&Dialector{version: "13.4 (Debian 13.4-1.pgdg100+1)"}).TranslateLock(nil, lock); err != nil || *translated != lock {
+		t.Errorf("lock should be kept, got `%+v` `%v`", translated, err)
+	}
+}
@@ -0,0 +1,67 @@
+package sqlite
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/binwen/sqldb/dialects"
+)
+
+// sqlite 不支持 ANALYZE，analyze 参数被忽略
+func (dia *Dialector) ExplainSQL(query string, analyze bool) string {
+	return "EXPLAIN QUERY PLAN " + query
+}
+
+func (dia *Dialector) ParseExplain(rows []map[string]interface{}, analyze bool) (*dialects.PlanNode, error) {
+	root := &dialects.PlanNode{Operation: "QUERY PLAN"}
+	nodes := map[string]*dialects.PlanNode{}
+
+	for _, row := range rows {
+		detail, ok := row["detail"].(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected sqlite query plan row: %v", row)
+		}
+
+		node := parseDetail(detail)
+		parent := root
+		if p, ok := nodes[fmt.Sprint(row["parent"])]; ok {
+			parent = p
+		}
+		if id, ok := row["id"]; ok {
+			nodes[fmt.Sprint(id)] = node
+		}
+		parent.Children = append(parent.Children, node)
+	}
+
+	return root, nil
+}
+
+// 解析如 `SCAN TABLE auth_user`、`SEARCH auth_user USING INTEGER PRIMARY KEY (rowid=?)` 的描述
+func parseDetail(detail string) *dialects.PlanNode {
+	node := &dialects.PlanNode{Detail: detail}
+	fields := strings.Fields(detail)
+	if len(fields) == 0 {
+		return node
+	}
+
+	node.Operation = fields[0]
+	if node.Operation != "SCAN" && node.Operation != "SEARCH" {
+		return node
+	}
+
+	fields = fields[1:]
+	if len(fields) > 0 && fields[0] == "TABLE" {
+		fields = fields[1:]
+	}
+	if len(fields) > 0 {
+		node.Table = fields[0]
+	}
+
+	for idx, field := range fields {
+		if field == "INDEX" && idx+1 < len(fields) {
+			node.Index = fields[idx+1]
+		}
+	}
+	node.FullScan = node.Operation == "SCAN" && !strings.Contains(detail, " USING ")
+	return node
+}
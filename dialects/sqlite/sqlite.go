@@ -1,6 +1,9 @@
 package sqlite
 
 import (
+	"fmt"
+	"sync"
+
 	_ "github.com/mattn/go-sqlite3"
 
 	"github.com/binwen/sqldb/clause"
@@ -8,19 +11,16 @@ import (
 )
 
 type Dialector struct {
-	queryer              dialects.Queryer
 	lastInsertIDReversed bool
 	withReturning        bool
+	versionMu            sync.Mutex
+	version              string
 }
 
 func init() {
 	dialects.RegisterDialector("sqlite3", &Dialector{lastInsertIDReversed: true})
 }
 
-func (dia *Dialector) SetQueryer(queryer dialects.Queryer) {
-	dia.queryer = queryer
-}
-
 func (dia *Dialector) LastInsertIDReversed() bool {
 	return dia.lastInsertIDReversed
 }
@@ -29,6 +29,34 @@ func (dia *Dialector) WithReturning() bool {
 	return dia.withReturning
 }
 
+// UPDATE、DELETE 的 RETURNING 需要 sqlite 3.35.0 及以上版本，版本查询成功后缓存，未能查询时按不支持处理
+func (dia *Dialector) UpdateReturning(queryer dialects.Queryer) bool {
+	dia.versionMu.Lock()
+	defer dia.versionMu.Unlock()
+	if dia.version == "" && queryer != nil {
+		_ = queryer.QueryRow("SELECT sqlite_version()").Scan(&dia.version)
+	}
+	return dia.version != "" && dialects.VersionAtLeast(dia.version, 3, 35)
+}
+
+// sqlite 没有行锁，写事务本身是串行的，因此忽略 FOR UPDATE/FOR SHARE，不支持 NOWAIT、SKIP LOCKED 等选项
+func (dia *Dialector) TranslateLock(queryer dialects.Queryer, lock clause.Lock) (*clause.Lock, error) {
+	if lock.Options != "" {
+		return nil, fmt.Errorf("%w: sqlite does not support row lock option `%s`", dialects.ErrUnsupportedLock, lock.Options)
+	}
+	return nil, nil
+}
+
+// INSERT ... SELECT ... ON CONFLICT 的 SELECT 需要 WHERE 子句(如 WHERE true)消除解析歧义
+func (dia *Dialector) InsertSelectNeedsWhere() bool {
+	return true
+}
+
+// SQLITE_MAX_VARIABLE_NUMBER 在 3.32.0 之前默认为 999
+func (dia *Dialector) MaxPlaceholders() int {
+	return 999
+}
+
 func (dia *Dialector) BindVarTo(writer clause.Writer, varIndex int, v interface{}) {
 	writer.WriteByte('?')
 }
@@ -39,6 +67,6 @@ func (dia *Dialector) QuoteTo(writer clause.Writer, str string) {
 	writer.WriteByte('`')
 }
 
-func (dia *Dialector) PKColumnNames(table string) (columnNames []string) {
+func (dia *Dialector) PKColumnNames(queryer dialects.Queryer, table string) (columnNames []string) {
 	return
 }
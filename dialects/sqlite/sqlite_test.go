@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+
+	"github.com/binwen/sqldb/dialects"
+)
+
+type queryer struct {
+	*sqlx.DB
+}
+
+func (q queryer) QueryRow(query string, args ...interface{}) *sqlx.Row {
+	return q.QueryRowx(query, args...)
+}
+
+func TestUpdateReturningVersion(t *testing.T) {
+	dia := &Dialector{}
+	if dia.UpdateReturning(nil) || dia.version != "" {
+		t.Errorf("version should not be cached without queryer, got `%v`", dia.version)
+	}
+
+	db, err := sqlx.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	var version string
+	if err := db.QueryRow("SELECT sqlite_version()").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	if supported := dia.UpdateReturning(queryer{db}); dia.version != version || supported != dialects.VersionAtLeast(version, 3, 35) {
+		t.Errorf("version `%v` should be queried after a queryer is given, got `%v` `%v`", version, dia.version, supported)
+	}
+}
@@ -0,0 +1,153 @@
+package sqldb
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 最终发送到数据库的 SQL 与参数
+type SQLStatement struct {
+	SQL  string
+	Vars []interface{}
+}
+
+func (s SQLStatement) String() string {
+	return s.Interpolate()
+}
+
+// 将参数代入占位符，生成便于阅读的 SQL，仅用于日志和调试，不能用于执行
+func (s SQLStatement) Interpolate() string {
+	var (
+		builder strings.Builder
+		quote   byte
+		idx     int
+	)
+	builder.Grow(len(s.SQL) + len(s.Vars)*8)
+
+	for i := 0; i < len(s.SQL); i++ {
+		c := s.SQL[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?' && idx < len(s.Vars):
+			builder.WriteString(interpolateVar(s.Vars[idx]))
+			idx++
+			continue
+		case c == '$' && i+1 < len(s.SQL) && s.SQL[i+1] >= '0' && s.SQL[i+1] <= '9':
+			j := i + 1
+			for j < len(s.SQL) && s.SQL[j] >= '0' && s.SQL[j] <= '9' {
+				j++
+			}
+			if n, _ := strconv.Atoi(s.SQL[i+1 : j]); n > 0 && n <= len(s.Vars) {
+				builder.WriteString(interpolateVar(s.Vars[n-1]))
+				i = j - 1
+				continue
+			}
+		}
+		builder.WriteByte(c)
+	}
+
+	return builder.String()
+}
+
+func interpolateVar(v interface{}) string {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if value, err := valuer.Value(); err == nil {
+			v = value
+		}
+	}
+
+	switch value := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + strings.Replace(value, "'", "''", -1) + "'"
+	case []byte:
+		return "'" + strings.Replace(string(value), "'", "''", -1) + "'"
+	case time.Time:
+		return "'" + value.Format("2006-01-02 15:04:05.999999999-07:00") + "'"
+	case bool:
+		if value {
+			return "TRUE"
+		}
+		return "FALSE"
+	case sql.NamedArg:
+		return interpolateVar(value.Value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// 在 dry run 模式下执行 op，仅构建 SQL 而不访问数据库，返回 op 中所有将要执行的语句；
+// op 对传入的会话副本进行操作，原会话不受影响，如:
+//
+//	stmts, err := db.Table("auth_user").Where("id = ?", 1).ToSQL(func(s *Session) error {
+//		_, err := s.Delete()
+//		return err
+//	})
+//
+// 注意: dry run 模式下 Query/Iterate 不返回结果集，QueryRow 返回的行 Scan 时返回 ErrDryRun；
+// 不查询主键等元数据，因此 Create 不生成 RETURNING 主键，FindInBatches 默认以 id 分批
+func (session *Session) ToSQL(op func(session *Session) error) ([]SQLStatement, error) {
+	var statements []SQLStatement
+	dryRunSession := session.Clone()
+	dryRunSession.dryRun = &statements
+
+	if err := op(dryRunSession); err != nil && err != ErrDryRun {
+		return statements, err
+	}
+	return statements, nil
+}
+
+// 记录 dry run 模式下的语句，返回 ErrDryRun 或绑定参数的错误
+func (session *Session) recordSQL(query string, args []interface{}) error {
+	query, args, err := session.db.convert(query, args)
+	if err != nil {
+		return err
+	}
+	*session.dryRun = append(*session.dryRun, SQLStatement{SQL: query, Vars: args})
+	return ErrDryRun
+}
+
+func (session *Session) queryContext(query string, args ...interface{}) (*sqlx.Rows, error) {
+	if session.dryRun != nil {
+		return nil, session.recordSQL(query, args)
+	}
+	return session.db.QueryContext(session.ctx, query, args...)
+}
+
+func (session *Session) execContext(query string, args ...interface{}) (sql.Result, error) {
+	if session.dryRun != nil {
+		return nil, session.recordSQL(query, args)
+	}
+	return session.db.ExecContext(session.ctx, query, args...)
+}
+
+func (session *Session) queryRowScan(query string, args []interface{}, dest ...interface{}) error {
+	rows, err := session.queryContext(query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return sql.ErrNoRows
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return err
+	}
+	return rows.Close()
+}
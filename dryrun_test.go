@@ -0,0 +1,87 @@
+package sqldb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/tests"
+)
+
+func TestToSQL(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2)
+		session := tests.DBEngine.Table("auth_user").Where("id in ?", []int{1, 2}).Where("username <> ?", "o'neil")
+
+		var users []tests.AuthUser
+		stmts, err := session.ToSQL(func(s *sqldb.Session) error {
+			return s.Find(&users)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(stmts) != 1 || len(stmts[0].Vars) != 3 || len(users) != 0 {
+			t.Fatalf("dry run find should record one statement with 3 vars and no rows, got `%v`", stmts)
+		}
+		if tests.DBEngine.DriverName() == "sqlite3" {
+			if stmts[0].SQL != "SELECT * FROM `auth_user` WHERE id in (?,?)  AND username <> ?" {
+				t.Errorf("dry run SQL not expected, got `%v`", stmts[0].SQL)
+			}
+			if stmts[0].String() != "SELECT * FROM `auth_user` WHERE id in (1,2)  AND username <> 'o''neil'" {
+				t.Errorf("interpolated SQL not expected, got `%v`", stmts[0].String())
+			}
+		}
+
+		stmts, err = session.ToSQL(func(s *sqldb.Session) error {
+			_, err := s.Delete()
+			return err
+		})
+		if err != nil || len(stmts) != 1 {
+			t.Errorf("dry run delete should record one statement, got `%v`, error `%v`", stmts, err)
+		}
+
+		stmts, err = tests.DBEngine.Table("auth_user").ToSQL(func(s *sqldb.Session) error {
+			_, err := s.Create(map[string]interface{}{"username": "user3", "age": 18, "is_superuser": true, "date_joined": time.Now()})
+			return err
+		})
+		if err != nil || len(stmts) != 1 {
+			t.Errorf("dry run create should record one statement, got `%v`, error `%v`", stmts, err)
+		}
+
+		stmts, err = tests.DBEngine.Table("auth_user").ToSQL(func(s *sqldb.Session) error {
+			_, err := s.Paginate(2, 10, &users)
+			return err
+		})
+		if err != nil || len(stmts) != 2 {
+			t.Errorf("dry run paginate should record count and find statements, got `%v`, error `%v`", stmts, err)
+		}
+
+		stmts, err = session.ToSQL(func(s *sqldb.Session) error {
+			var id int
+			if err := s.Select("id").QueryRow().Scan(&id); err != sqldb.ErrDryRun {
+				t.Errorf("dry run query row should return ErrDryRun, got `%v`", err)
+			}
+			return nil
+		})
+		if err != nil || len(stmts) != 1 {
+			t.Errorf("dry run query row should record one statement, got `%v`, error `%v`", stmts, err)
+		}
+
+		if count, err := session.Count(); err != nil {
+			t.Error(err)
+		} else if count != 2 {
+			t.Errorf("session should be usable after dry run, count should be `2`, got `%v`", count)
+		}
+	})
+}
+
+func TestInterpolate(t *testing.T) {
+	stmt := sqldb.SQLStatement{
+		SQL:  `SELECT * FROM "user" WHERE "name" = $2 AND "note" = '$1?' AND "id" = $1 AND "deleted" IS $3`,
+		Vars: []interface{}{10, "tom", nil},
+	}
+	expected := `SELECT * FROM "user" WHERE "name" = 'tom' AND "note" = '$1?' AND "id" = 10 AND "deleted" IS NULL`
+	if stmt.Interpolate() != expected {
+		t.Errorf("interpolated SQL should be `%v`, got `%v`", expected, stmt.Interpolate())
+	}
+}
@@ -26,6 +26,7 @@ type ConnectionEngine struct {
 	slaves    []*Connection
 	policy    IPolicy
 	Dialector dialects.Dialector
+	nowFunc   func() time.Time
 }
 
 func (engine *ConnectionEngine) Slave() *Connection {
@@ -73,6 +74,7 @@ type EngineGroup struct {
 	engineGroup  map[string]*ConnectionEngine
 	defaultSqlDB *SqlDB
 	showSQL      bool
+	queries      *QueryRegistry
 }
 
 func (eg *EngineGroup) Use(dbAlias ...string) *SqlDB {
@@ -166,6 +168,19 @@ func (eg *EngineGroup) SetPolicy(dbAlias string, policy IPolicy) *EngineGroup {
 	return eg
 }
 
+// 设置自动填充时间字段、软删除时使用的时钟，默认为 time.Now，可在测试中替换
+func (eg *EngineGroup) SetNowFunc(nowFunc func() time.Time) *EngineGroup {
+	for _, engine := range eg.engineGroup {
+		engine.nowFunc = nowFunc
+	}
+	return eg
+}
+
+// 当前时间，使用 SetNowFunc 设置的时钟
+func (eg *EngineGroup) Now() time.Time {
+	return eg.defaultSqlDB.now()
+}
+
 func (eg *EngineGroup) Close() {
 	for _, engine := range eg.engineGroup {
 		if err := engine.master.Close(); err != nil {
@@ -196,6 +211,32 @@ func (eg *EngineGroup) RawContext(ctx context.Context, query string, args ...int
 	return eg.defaultSqlDB.RawContext(ctx, query, args...)
 }
 
+func (eg *EngineGroup) Template(text string, data interface{}) *RawSession {
+	return eg.defaultSqlDB.Template(text, data)
+}
+
+func (eg *EngineGroup) TemplateContext(ctx context.Context, text string, data interface{}) *RawSession {
+	return eg.defaultSqlDB.TemplateContext(ctx, text, data)
+}
+
+// 加载 SQL 文件中的命名查询
+func (eg *EngineGroup) LoadSQLFiles(paths ...string) error {
+	return eg.queries.LoadFiles(paths...)
+}
+
+func (eg *EngineGroup) Queries() *QueryRegistry {
+	return eg.queries
+}
+
+// 使用已加载的命名查询创建 RawSession，查询不存在或参数与占位符不一致时返回的 RawSession 带有错误
+func (eg *EngineGroup) Named(name string, args ...interface{}) *RawSession {
+	return eg.defaultSqlDB.named(context.Background(), eg.queries, name, args)
+}
+
+func (eg *EngineGroup) NamedContext(ctx context.Context, name string, args ...interface{}) *RawSession {
+	return eg.defaultSqlDB.named(ctx, eg.queries, name, args)
+}
+
 func (eg *EngineGroup) Rebind(query string) string {
 	return eg.defaultSqlDB.Rebind(query)
 }
@@ -252,6 +293,10 @@ func (eg *EngineGroup) DriverName() string {
 	return eg.defaultSqlDB.DriverName()
 }
 
+func (eg *EngineGroup) Lock(ctx context.Context, name string, timeout time.Duration, opts ...LockOptions) (*NamedLock, error) {
+	return eg.defaultSqlDB.Lock(ctx, name, timeout, opts...)
+}
+
 func NewDBEngineGroup(conf DBConfig, showSQL bool) (engineGroup *EngineGroup, err error) {
 	if len(conf) == 0 {
 		return nil, errors.New("database connection configuration cannot be empty")
@@ -263,6 +308,7 @@ func NewDBEngineGroup(conf DBConfig, showSQL bool) (engineGroup *EngineGroup, er
 	engineGroup = &EngineGroup{
 		engineGroup: map[string]*ConnectionEngine{},
 		showSQL:     showSQL,
+		queries:     NewQueryRegistry(),
 	}
 	for dbAlias, dbConfig := range conf {
 		switch dbConfig.(type) {
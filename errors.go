@@ -5,4 +5,11 @@ import "errors"
 var (
 	ErrRecordNotFound     = errors.New("record not found")
 	ErrMissingWhereClause = errors.New("missing WHERE clause while deleting")
+	ErrInvalidCursor      = errors.New("invalid pagination cursor")
+	ErrDryRun             = errors.New("statement not executed in dry run mode")
+	ErrLockWithoutTx      = errors.New("row lock must be used in a transaction")
+	ErrLockTimeout        = errors.New("timed out waiting for lock")
+	ErrLockLost           = errors.New("lock is no longer held")
+	ErrStaleObject        = errors.New("stale object: record was modified or deleted by another update")
+	ErrExplainAnalyze     = errors.New("explain analyze executes the statement, only queries are allowed")
 )
@@ -0,0 +1,84 @@
+package sqldb
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/binwen/sqldb/dialects"
+)
+
+func explain(ctx context.Context, db *SqlDB, query string, args []interface{}, analyze bool) (*dialects.PlanNode, error) {
+	explainer, ok := db.engine.Dialector.(dialects.Explainer)
+	if !ok {
+		return nil, fmt.Errorf("dialect of driver `%s` does not support explain", db.DriverName())
+	}
+
+	rows, err := db.QueryContext(ctx, explainer.ExplainSQL(query, analyze), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var planRows []map[string]interface{}
+	for rows.Next() {
+		mapping, err := MakeMapScan(rows)
+		if err != nil {
+			return nil, err
+		}
+		planRows = append(planRows, mapping)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return explainer.ParseExplain(planRows, analyze)
+}
+
+// 返回查询的执行计划，analyze 为 true 时会真正执行语句(sqlite 不支持，忽略该参数)
+func (session *Session) Explain(analyze bool) (*dialects.PlanNode, error) {
+	session = session.getInstance()
+	defer session.Clear()
+	if session.Error != nil {
+		return nil, session.Error
+	}
+
+	if session.statement.SQL.String() == "" {
+		if err := session.buildQuerySQL(); err != nil {
+			return nil, err
+		}
+	}
+
+	return explain(session.ctx, session.db, session.statement.SQL.String(), session.statement.SQLVars, analyze)
+}
+
+// 返回原生语句的执行计划；EXPLAIN ANALYZE 会真正执行语句，因此 analyze 为 true 时只允许查询语句，
+// INSERT、UPDATE、DELETE 等语句返回 ErrExplainAnalyze
+func (raw *RawSession) Explain(analyze bool) (*dialects.PlanNode, error) {
+	if raw.err != nil {
+		return nil, raw.err
+	}
+	if analyze && !isSelectQuery(raw.query) {
+		return nil, ErrExplainAnalyze
+	}
+	return explain(raw.ctx, raw.db, raw.query, raw.vars, analyze)
+}
+
+var modifyPattern = regexp.MustCompile(`(?i)\b(INSERT|UPDATE|DELETE|REPLACE|MERGE)\b`)
+
+// 语句是否为只读查询：以 SELECT 开头，或以 WITH 开头且不含修改语句
+func isSelectQuery(query string) bool {
+	query = strings.TrimLeft(query, " \t\r\n(")
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return false
+	}
+	switch strings.ToUpper(fields[0]) {
+	case "SELECT":
+		return true
+	case "WITH":
+		return !modifyPattern.MatchString(query)
+	}
+	return false
+}
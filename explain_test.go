@@ -0,0 +1,42 @@
+package sqldb_test
+
+import (
+	"testing"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/tests"
+)
+
+func TestExplain(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3)
+		plan, err := tests.DBEngine.Table("auth_user").Where("age > ?", 10).Explain(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if scans := plan.FullScans(); len(scans) != 1 || scans[0].Table != "auth_user" {
+			t.Errorf("plan should flag one full table scan on `auth_user`, got `%+v`", scans)
+		}
+
+		plan, err = tests.DBEngine.Raw("select * from auth_user where id = ?", 1).Explain(false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(plan.Children) == 0 {
+			t.Error("plan should have children nodes")
+		}
+		if scans := plan.FullScans(); len(scans) != 0 {
+			t.Errorf("primary key lookup should not be a full table scan, got `%+v`", scans[0])
+		}
+
+		if _, err := tests.DBEngine.Raw("delete from auth_user where id = ?", 1).Explain(true); err != sqldb.ErrExplainAnalyze {
+			t.Errorf("explain analyze of delete should return ErrExplainAnalyze, got `%v`", err)
+		}
+		if count, _ := tests.DBEngine.Table("auth_user").Count(); count != 3 {
+			t.Errorf("explain analyze should not delete rows, got `%v` users", count)
+		}
+		if _, err := tests.DBEngine.Raw("delete from auth_user where id = ?", 1).Explain(false); err != nil {
+			t.Errorf("explain without analyze should support delete, got `%v`", err)
+		}
+	})
+}
@@ -0,0 +1,185 @@
+package sqldb
+
+import (
+	"context"
+	"reflect"
+)
+
+// 模型可实现以下钩子接口，db 为执行操作的连接；带钩子的写操作在事务中执行，
+// before 钩子返回错误时终止操作，任一钩子出错时回滚
+type BeforeCreateHook interface {
+	BeforeCreate(ctx context.Context, db *SqlDB) error
+}
+
+type AfterCreateHook interface {
+	AfterCreate(ctx context.Context, db *SqlDB) error
+}
+
+type BeforeUpdateHook interface {
+	BeforeUpdate(ctx context.Context, db *SqlDB) error
+}
+
+type AfterUpdateHook interface {
+	AfterUpdate(ctx context.Context, db *SqlDB) error
+}
+
+type BeforeDeleteHook interface {
+	BeforeDelete(ctx context.Context, db *SqlDB) error
+}
+
+type AfterDeleteHook interface {
+	AfterDelete(ctx context.Context, db *SqlDB) error
+}
+
+type AfterFindHook interface {
+	AfterFind(ctx context.Context, db *SqlDB) error
+}
+
+type hookKind int
+
+const (
+	beforeCreate hookKind = iota
+	afterCreate
+	beforeUpdate
+	afterUpdate
+	beforeDelete
+	afterDelete
+	afterFind
+)
+
+var hookTypes = map[hookKind]reflect.Type{
+	beforeCreate: reflect.TypeOf((*BeforeCreateHook)(nil)).Elem(),
+	afterCreate:  reflect.TypeOf((*AfterCreateHook)(nil)).Elem(),
+	beforeUpdate: reflect.TypeOf((*BeforeUpdateHook)(nil)).Elem(),
+	afterUpdate:  reflect.TypeOf((*AfterUpdateHook)(nil)).Elem(),
+	beforeDelete: reflect.TypeOf((*BeforeDeleteHook)(nil)).Elem(),
+	afterDelete:  reflect.TypeOf((*AfterDeleteHook)(nil)).Elem(),
+	afterFind:    reflect.TypeOf((*AfterFindHook)(nil)).Elem(),
+}
+
+func (kind hookKind) call(ctx context.Context, db *SqlDB, model interface{}) error {
+	switch kind {
+	case beforeCreate:
+		if hook, ok := model.(BeforeCreateHook); ok {
+			return hook.BeforeCreate(ctx, db)
+		}
+	case afterCreate:
+		if hook, ok := model.(AfterCreateHook); ok {
+			return hook.AfterCreate(ctx, db)
+		}
+	case beforeUpdate:
+		if hook, ok := model.(BeforeUpdateHook); ok {
+			return hook.BeforeUpdate(ctx, db)
+		}
+	case afterUpdate:
+		if hook, ok := model.(AfterUpdateHook); ok {
+			return hook.AfterUpdate(ctx, db)
+		}
+	case beforeDelete:
+		if hook, ok := model.(BeforeDeleteHook); ok {
+			return hook.BeforeDelete(ctx, db)
+		}
+	case afterDelete:
+		if hook, ok := model.(AfterDeleteHook); ok {
+			return hook.AfterDelete(ctx, db)
+		}
+	case afterFind:
+		if hook, ok := model.(AfterFindHook); ok {
+			return hook.AfterFind(ctx, db)
+		}
+	}
+	return nil
+}
+
+// 模型(struct 或其 slice)的元素类型是否实现了任一钩子
+func hasHooks(model reflect.Value, kinds ...hookKind) bool {
+	if !model.IsValid() {
+		return false
+	}
+	typ := model.Type()
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+	for _, kind := range kinds {
+		if reflect.PtrTo(typ).Implements(hookTypes[kind]) {
+			return true
+		}
+	}
+	return false
+}
+
+// 依次调用模型中每个元素的钩子，元素可寻址时使用指针调用
+func callHooks(ctx context.Context, db *SqlDB, model reflect.Value, kind hookKind) error {
+	model = reflect.Indirect(model)
+	switch model.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < model.Len(); i++ {
+			if err := callHooks(ctx, db, model.Index(i), kind); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		if model.CanAddr() {
+			return kind.call(ctx, db, model.Addr().Interface())
+		}
+		return kind.call(ctx, db, model.Interface())
+	}
+	return nil
+}
+
+// 设置修改、删除时调用钩子的模型，未设置时修改、删除不调用钩子；
+// 模型声明了 version 字段时修改使用乐观锁，声明了 autoUpdateTime 字段时修改自动填充当前时间，如:
+//
+//	db.Table("auth_user").Model(&user).Where("id = ?", user.Id).Update("age", 30)
+func (session *Session) Model(value interface{}) *Session {
+	session = session.getInstance()
+	session.model = value
+	return session
+}
+
+// 修改、删除时调用钩子的模型，只使用 Model 设置的值
+func (session *Session) hookModel() reflect.Value {
+	if session.model != nil {
+		return reflect.ValueOf(session.model)
+	}
+	return reflect.Value{}
+}
+
+// 执行带钩子的写操作：模型实现了 before 或 after 钩子时，在事务中依次执行 before 钩子、操作及 after 钩子，
+// 已在事务中时使用当前事务；dry run 模式下不调用钩子
+func (session *Session) withHooks(model reflect.Value, before, after hookKind, op func(session *Session) error) error {
+	if session.skipHooks || session.dryRun != nil || !hasHooks(model, before, after) {
+		return op(session)
+	}
+
+	run := func(db *SqlDB) error {
+		if err := callHooks(session.ctx, db, model, before); err != nil {
+			return err
+		}
+		hooked := session.Clone()
+		hooked.db = db
+		hooked.skipHooks = true
+		if err := op(hooked); err != nil {
+			return err
+		}
+		return callHooks(session.ctx, db, model, after)
+	}
+
+	if session.db.tx != nil {
+		return run(session.db)
+	}
+	return session.db.TxContext(session.ctx, run)
+}
+
+// 结构体在不可寻址时复制一份，使指针接收者的钩子可以修改字段
+func addressable(value reflect.Value) reflect.Value {
+	if value.Kind() != reflect.Struct && value.Kind() != reflect.Array || value.CanAddr() {
+		return value
+	}
+	copied := reflect.New(value.Type()).Elem()
+	copied.Set(value)
+	return copied
+}
@@ -0,0 +1,153 @@
+package sqldb_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/tests"
+)
+
+var (
+	hookCalls      []string
+	errInvalidName = errors.New("group name cannot be empty")
+	errLocked      = errors.New("group is locked")
+)
+
+type hookGroup struct {
+	Id    int
+	Name  string `db:"name"`
+	Label string `db:"-"`
+}
+
+func (group *hookGroup) BeforeCreate(ctx context.Context, db *sqldb.SqlDB) error {
+	hookCalls = append(hookCalls, "before create")
+	if group.Name = strings.ToLower(strings.TrimSpace(group.Name)); group.Name == "" {
+		return errInvalidName
+	}
+	return nil
+}
+
+func (group *hookGroup) AfterCreate(ctx context.Context, db *sqldb.SqlDB) error {
+	hookCalls = append(hookCalls, "after create")
+	if group.Name == "rollback" {
+		return errLocked
+	}
+	_, err := db.Table("auth_user_groups").Create(map[string]interface{}{"user_id": 1, "group_id": group.Id})
+	return err
+}
+
+func (group *hookGroup) BeforeUpdate(ctx context.Context, db *sqldb.SqlDB) error {
+	hookCalls = append(hookCalls, "before update")
+	return nil
+}
+
+func (group *hookGroup) AfterUpdate(ctx context.Context, db *sqldb.SqlDB) error {
+	hookCalls = append(hookCalls, "after update")
+	return nil
+}
+
+func (group *hookGroup) BeforeDelete(ctx context.Context, db *sqldb.SqlDB) error {
+	hookCalls = append(hookCalls, "before delete")
+	if group.Id == 1 {
+		return errLocked
+	}
+	return nil
+}
+
+func (group *hookGroup) AfterDelete(ctx context.Context, db *sqldb.SqlDB) error {
+	hookCalls = append(hookCalls, "after delete")
+	return nil
+}
+
+func (group *hookGroup) AfterFind(ctx context.Context, db *sqldb.SqlDB) error {
+	group.Label = "#" + group.Name
+	return nil
+}
+
+func TestCreateHooks(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		hookCalls = nil
+		group := hookGroup{Id: 1, Name: "  Admin "}
+		if _, err := tests.DBEngine.Table("auth_group").Create(&group); err != nil {
+			t.Fatal(err)
+		}
+		if group.Name != "admin" || strings.Join(hookCalls, ",") != "before create,after create" {
+			t.Errorf("create hooks should be called, got `%+v` `%v`", group, hookCalls)
+		}
+		if count, err := tests.DBEngine.Table("auth_user_groups").Where("group_id = ?", 1).Count(); err != nil || count != 1 {
+			t.Errorf("after create hook should write in the same transaction, got `%v` `%v`", count, err)
+		}
+
+		if _, err := tests.DBEngine.Table("auth_group").Create(hookGroup{Id: 2, Name: " "}); err != errInvalidName {
+			t.Errorf("before create error should abort, got `%v`", err)
+		}
+		if _, err := tests.DBEngine.Table("auth_group").Create(&hookGroup{Id: 3, Name: "rollback"}); err != errLocked {
+			t.Errorf("after create error should be returned, got `%v`", err)
+		}
+		if _, err := tests.DBEngine.Table("auth_group").BulkCreate([]hookGroup{{Id: 4, Name: "Ops"}, {Id: 5}}); err != errInvalidName {
+			t.Errorf("before create error should abort bulk create, got `%v`", err)
+		}
+		if count, err := tests.DBEngine.Table("auth_group").Count(); err != nil || count != 1 {
+			t.Errorf("aborted creates should be rolled back, got `%v` `%v`", count, err)
+		}
+
+		groups := []*hookGroup{{Id: 6, Name: "Dev"}, {Id: 7, Name: "QA"}}
+		if _, err := tests.DBEngine.Table("auth_group").BulkCreate(groups); err != nil {
+			t.Fatal(err)
+		}
+		if groups[0].Name != "dev" || groups[1].Name != "qa" {
+			t.Errorf("bulk create hooks should modify every element, got `%v` `%v`", groups[0].Name, groups[1].Name)
+		}
+	})
+}
+
+func TestUpdateDeleteFindHooks(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthGroup(1, 2)
+		table := "auth_group"
+
+		hookCalls = nil
+		if _, err := tests.DBEngine.Table(table).Model(&hookGroup{Id: 2}).Where("id = ?", 2).Update("name", "renamed"); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(hookCalls, ",") != "before update,after update" {
+			t.Errorf("update hooks should be called for model, got `%v`", hookCalls)
+		}
+		hookCalls = nil
+		if _, err := tests.DBEngine.Table(table).Where("id = ?", 2).Update("name", "group2"); err != nil {
+			t.Fatal(err)
+		}
+		if len(hookCalls) != 0 {
+			t.Errorf("update hooks should not be called without model, got `%v`", hookCalls)
+		}
+
+		if _, err := tests.DBEngine.Table(table).Model(&hookGroup{Id: 1}).Where("id = ?", 1).Delete(); err != errLocked {
+			t.Errorf("before delete error should abort, got `%v`", err)
+		}
+		if _, err := tests.DBEngine.Table(table).Model(&hookGroup{Id: 2}).Where("id = ?", 2).Delete(); err != nil {
+			t.Fatal(err)
+		}
+		if strings.Join(hookCalls, ",") != "before delete,before delete,after delete" {
+			t.Errorf("delete hooks should be called, got `%v`", hookCalls)
+		}
+		if count, err := tests.DBEngine.Table("auth_group").Count(); err != nil || count != 1 {
+			t.Errorf("only unlocked group should be deleted, got `%v` `%v`", count, err)
+		}
+
+		var group hookGroup
+		if err := tests.DBEngine.Table("auth_group").Select("id", "name").First(&group); err != nil {
+			t.Fatal(err)
+		} else if group.Label != "#group1" {
+			t.Errorf("after find hook should be called, got `%+v`", group)
+		}
+		var groups []*hookGroup
+		if err := tests.DBEngine.Raw("select id, name from auth_group").Fetch(&groups); err != nil {
+			t.Fatal(err)
+		} else if len(groups) != 1 || groups[0].Label != "#group1" {
+			t.Errorf("after find hook should be called for raw fetch, got `%+v`", groups)
+		}
+	})
+}
@@ -0,0 +1,67 @@
+// 任务队列(queue)与事务性发件箱(outbox)共用的时钟、重试间隔及建表逻辑
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+
+	"github.com/binwen/sqldb"
+)
+
+// MySQL 索引已存在的错误码
+const mysqlDupKeyName = 1061
+
+// 重试间隔从 1 秒开始按失败次数指数增长: 1s、2s、4s ...，最长为 max
+func Backoff(attempts int, max time.Duration) time.Duration {
+	if attempts <= 0 {
+		return time.Second
+	}
+	if attempts > 32 {
+		return max
+	}
+	if backoff := time.Second << uint(attempts-1); backoff < max {
+		return backoff
+	}
+	return max
+}
+
+// 未指定时钟时使用数据库的时钟，与自动填充时间字段一致，可通过 SetNowFunc 替换
+func Clock(db *sqldb.EngineGroup, clock func() time.Time) func() time.Time {
+	if clock != nil {
+		return clock
+	}
+	return db.Now
+}
+
+// 毫秒时间戳
+func Millis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// 创建表及索引，columns 为字段定义，其中的两个 %s 依次替换为当前数据库的自增主键及二进制类型，
+// indexes 为索引名后缀到索引字段的映射；MySQL 不支持 CREATE INDEX IF NOT EXISTS，索引已存在的错误将被忽略
+func Migrate(ctx context.Context, db *sqldb.EngineGroup, table, columns string, indexes map[string]string) error {
+	id, blob, options, ifNotExists := "INTEGER PRIMARY KEY AUTOINCREMENT", "BLOB", "", "IF NOT EXISTS "
+	switch db.DriverName() {
+	case "mysql":
+		id, blob, options, ifNotExists = "BIGINT NOT NULL AUTO_INCREMENT PRIMARY KEY", "LONGBLOB", " ENGINE=InnoDB DEFAULT CHARSET=utf8mb4", ""
+	case "postgres":
+		id, blob = "BIGSERIAL PRIMARY KEY", "BYTEA"
+	}
+
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (\n%s\n)%s", table, fmt.Sprintf(columns, id, blob), options)); err != nil {
+		return err
+	}
+	for name, fields := range indexes {
+		_, err := db.ExecContext(ctx, fmt.Sprintf("CREATE INDEX %sidx_%s_%s ON %s (%s)", ifNotExists, table, name, table, fields))
+		var mysqlErr *mysql.MySQLError
+		if err != nil && !(errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDupKeyName) {
+			return err
+		}
+	}
+	return nil
+}
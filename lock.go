@@ -0,0 +1,111 @@
+package sqldb
+
+import (
+	"errors"
+
+	"github.com/binwen/sqldb/clause"
+	"github.com/binwen/sqldb/dialects"
+)
+
+// 对查询的行加排他锁(FOR UPDATE)，必须在事务中使用，加锁的查询总是在主库执行
+func (session *Session) ForUpdate() *Session {
+	session = session.getInstance()
+	return session.addLock(clause.Lock{Strength: "UPDATE"})
+}
+
+// 对查询的行加共享锁(FOR SHARE)，必须在事务中使用
+func (session *Session) ForShare() *Session {
+	session = session.getInstance()
+	return session.addLock(clause.Lock{Strength: "SHARE"})
+}
+
+// 跳过已被其他事务锁定的行，需在 ForUpdate 或 ForShare 之后调用
+func (session *Session) SkipLocked() *Session {
+	session = session.getInstance()
+	return session.updateLock(func(lock *clause.Lock) {
+		lock.Options = "SKIP LOCKED"
+	})
+}
+
+// 行已被其他事务锁定时立即返回错误而不等待，需在 ForUpdate 或 ForShare 之后调用
+func (session *Session) NoWait() *Session {
+	session = session.getInstance()
+	return session.updateLock(func(lock *clause.Lock) {
+		lock.Options = "NOWAIT"
+	})
+}
+
+// 只锁定指定表(或别名)的行，用于连接查询，需在 ForUpdate 或 ForShare 之后调用
+func (session *Session) Of(table string) *Session {
+	session = session.getInstance()
+	return session.updateLock(func(lock *clause.Lock) {
+		lock.Table = clause.Table{Name: table}
+	})
+}
+
+func (session *Session) addLock(lock clause.Lock) *Session {
+	if session.db.tx == nil && session.dryRun == nil {
+		session.AddError(ErrLockWithoutTx)
+		return session
+	}
+
+	session.statement.AddClause(clause.For{Locks: []clause.Lock{lock}})
+	return session
+}
+
+func (session *Session) updateLock(fn func(lock *clause.Lock)) *Session {
+	c, ok := session.statement.Clauses["FOR"]
+	f, _ := c.Expression.(clause.For)
+	if !ok || len(f.Locks) == 0 {
+		if session.Error == nil {
+			session.AddError(errors.New("lock option must be used after ForUpdate or ForShare"))
+		}
+		return session
+	}
+
+	locks := append([]clause.Lock(nil), f.Locks...)
+	fn(&locks[len(locks)-1])
+	if translator, ok := session.statement.Dialector.(dialects.LockTranslator); ok {
+		if _, err := translator.TranslateLock(session.queryer(), locks[len(locks)-1]); err != nil {
+			session.AddError(err)
+			return session
+		}
+	}
+
+	c.Expression = clause.For{Locks: locks}
+	session.statement.Clauses["FOR"] = c
+	return session
+}
+
+// 构建查询前按方言转换行锁，转换后没有锁时去掉 FOR 子句
+func (session *Session) translateLocks() {
+	c, ok := session.statement.Clauses["FOR"]
+	if !ok {
+		return
+	}
+	translator, ok := session.statement.Dialector.(dialects.LockTranslator)
+	if !ok {
+		return
+	}
+
+	var locks []clause.Lock
+	if f, ok := c.Expression.(clause.For); ok {
+		for _, lock := range f.Locks {
+			translated, err := translator.TranslateLock(session.queryer(), lock)
+			if err != nil {
+				session.AddError(err)
+				continue
+			}
+			if translated != nil {
+				locks = append(locks, *translated)
+			}
+		}
+	}
+
+	if len(locks) == 0 {
+		delete(session.statement.Clauses, "FOR")
+		return
+	}
+	c.Expression = clause.For{Locks: locks}
+	session.statement.Clauses["FOR"] = c
+}
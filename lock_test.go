@@ -0,0 +1,54 @@
+package sqldb_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/tests"
+)
+
+func TestRowLock(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2)
+
+		var user tests.AuthUser
+		if err := tests.DBEngine.Table("auth_user").Where("id = ?", 1).ForUpdate().First(&user); err != sqldb.ErrLockWithoutTx {
+			t.Errorf("lock outside transaction should return `%v`, got `%v`", sqldb.ErrLockWithoutTx, err)
+		}
+
+		err := tests.DBEngine.Tx(func(tx *sqldb.SqlDB) error {
+			if err := tx.Table("auth_user").Where("id = ?", 1).ForUpdate().Of("auth_user").First(&user); err != nil {
+				return err
+			}
+			if user.Id != 1 {
+				t.Errorf("user id should be `1`, got `%v`", user.Id)
+			}
+
+			var ids []int
+			if err := tx.Table("auth_user").SkipLocked().Find(&ids); err == nil || !strings.Contains(err.Error(), "after ForUpdate or ForShare") {
+				t.Errorf("lock option without lock should return error, got `%v`", err)
+			}
+
+			if tx.DriverName() == "sqlite3" {
+				if err := tx.Table("auth_user").ForShare().NoWait().Find(&ids); err == nil {
+					t.Error("sqlite should reject NOWAIT")
+				}
+
+				statements, err := tx.Table("auth_user").Where("id = ?", 2).ToSQL(func(s *sqldb.Session) error {
+					return s.ForUpdate().Find(&ids)
+				})
+				if err != nil {
+					return err
+				}
+				if len(statements) != 1 || strings.Contains(statements[0].SQL, "FOR") {
+					t.Errorf("sqlite should drop row lock, got `%v`", statements)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,286 @@
+package sqldb
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/binwen/sqldb/clause"
+)
+
+// 自动时间字段的存储格式
+type timeUnit int
+
+const (
+	unitTime   timeUnit = iota // time.Time
+	unitSecond                 // unix 秒
+	unitMilli                  // unix 毫秒
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// 由 autoCreateTime、autoUpdateTime 标签声明的时间字段，如:
+//
+//	CreatedAt time.Time `db:"created_at,autoCreateTime"`
+//	UpdatedAt int64     `db:"updated_at,autoUpdateTime=milli"`
+type autoTimeField struct {
+	column string
+	index  []int
+	create bool
+	update bool
+	unit   timeUnit
+}
+
+func (field autoTimeField) value(now time.Time) interface{} {
+	switch field.unit {
+	case unitSecond:
+		return now.Unix()
+	case unitMilli:
+		return now.UnixNano() / int64(time.Millisecond)
+	}
+	return now
+}
+
+// 由 version 标签声明的乐观锁版本字段，如:
+//
+//	Version int `db:"version,version"`
+type versionField struct {
+	column string
+	index  []int
+}
+
+// 模型的表结构信息，由 struct 标签解析
+type modelSchema struct {
+	typ       reflect.Type
+	autoTimes []autoTimeField
+	version   *versionField
+	relations map[string]*relation // 以字段名为键
+}
+
+var (
+	modelMu      sync.RWMutex
+	modelMapping = map[string]*modelSchema{}
+	schemaCache  sync.Map
+)
+
+// 注册表(或表别名)对应的模型，修改语句等不传入 struct 的操作据此应用模型的标签，
+// 如 BulkUpdate 自动设置 autoUpdateTime 字段
+func RegisterModel(table string, model interface{}) {
+	if schema := parseSchema(reflect.TypeOf(model)); schema != nil {
+		modelMu.Lock()
+		defer modelMu.Unlock()
+		modelMapping[table] = schema
+	}
+}
+
+// 移除表(或表别名)注册的模型
+func UnregisterModel(table string) {
+	modelMu.Lock()
+	defer modelMu.Unlock()
+	delete(modelMapping, table)
+}
+
+// 解析 struct 类型的标签，非 struct 类型返回 nil
+func parseSchema(typ reflect.Type) *modelSchema {
+	for typ != nil && (typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array) {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct || typ == timeType {
+		return nil
+	}
+	if schema, ok := schemaCache.Load(typ); ok {
+		return schema.(*modelSchema)
+	}
+
+	schema := &modelSchema{typ: typ}
+	for name, fi := range mapper.mapper.TypeMap(typ).Names {
+		// 与 FieldMap 一致，忽略非嵌入的 struct 字段中的子字段
+		if (fi.Parent.Zero.Kind() == reflect.Struct || (fi.Zero.Kind() == reflect.Ptr && fi.Zero.Type().Elem().Kind() == reflect.Struct)) && !fi.Parent.Field.Anonymous {
+			continue
+		}
+		if _, ok := fi.Options["version"]; ok {
+			switch fi.Field.Type.Kind() {
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+				reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+				schema.version = &versionField{column: name, index: fi.Index}
+			}
+		}
+
+		createUnit, create := fi.Options["autoCreateTime"]
+		updateUnit, update := fi.Options["autoUpdateTime"]
+		if !create && !update {
+			continue
+		}
+
+		unit := createUnit
+		if !create {
+			unit = updateUnit
+		}
+		field := autoTimeField{column: name, index: fi.Index, create: create, update: update, unit: unitTime}
+		fieldType := fi.Field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		switch fieldType.Kind() {
+		case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+			field.unit = unitSecond
+			if strings.EqualFold(unit, "milli") {
+				field.unit = unitMilli
+			}
+		}
+		schema.autoTimes = append(schema.autoTimes, field)
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if tag, ok := field.Tag.Lookup("relation"); ok {
+			if schema.relations == nil {
+				schema.relations = map[string]*relation{}
+			}
+			schema.relations[field.Name] = parseRelation(field, tag)
+		}
+	}
+
+	schemaCache.Store(typ, schema)
+	return schema
+}
+
+// 当前表注册的模型
+func (session *Session) tableSchema() *modelSchema {
+	if len(session.statement.Tables) == 0 {
+		return nil
+	}
+	table := session.statement.Tables[0]
+	modelMu.RLock()
+	defer modelMu.RUnlock()
+	if schema, ok := modelMapping[table.Name]; ok {
+		return schema
+	}
+	return modelMapping[table.Alias]
+}
+
+// 创建时填充 autoCreateTime、autoUpdateTime 字段：未设置或为零值时使用当前时间，
+// struct 数据可寻址时同时回写到字段中；存在 ON CONFLICT DO UPDATE 时同时更新 autoUpdateTime 字段
+func (session *Session) setCreateTimes(direct reflect.Value, values *clause.Values) {
+	schema := parseSchema(direct.Type())
+	if schema == nil {
+		schema = session.tableSchema()
+	}
+	if schema == nil || len(schema.autoTimes) == 0 {
+		return
+	}
+
+	now := session.db.now()
+	for _, field := range schema.autoTimes {
+		idx := -1
+		for i, column := range values.Columns {
+			if column.Name == field.column {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			values.Columns = append(values.Columns, clause.Column{Name: field.column})
+			for i := range values.Values {
+				values.Values[i] = append(values.Values[i], nil)
+			}
+			idx = len(values.Columns) - 1
+		}
+		for _, row := range values.Values {
+			if isZeroValue(row[idx]) {
+				row[idx] = field.value(now)
+			}
+		}
+	}
+	session.writeBackTimes(direct, schema, now)
+
+	if onConflict, ok := session.statement.Clauses["ON CONFLICT"].Expression.(clause.OnConflict); ok && !onConflict.DoNothing {
+		for _, field := range schema.autoTimes {
+			if field.update && !hasAssignment(onConflict.DoUpdates, field.column) {
+				onConflict.DoUpdates.Assignments = append(onConflict.DoUpdates.Assignments, clause.Assignment{
+					Column: clause.Column{Name: field.column}, Value: field.value(now),
+				})
+			}
+		}
+		session.statement.AddClause(onConflict)
+	}
+}
+
+// 将自动填充的时间回写到可寻址的 struct 零值字段
+func (session *Session) writeBackTimes(direct reflect.Value, schema *modelSchema, now time.Time) {
+	switch direct.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < direct.Len(); i++ {
+			session.writeBackTimes(reflect.Indirect(direct.Index(i)), schema, now)
+		}
+	case reflect.Struct:
+		if direct.Type() != schema.typ {
+			return
+		}
+		for _, field := range schema.autoTimes {
+			v := direct.FieldByIndex(field.index)
+			if !v.CanSet() || !v.IsZero() {
+				continue
+			}
+			value := reflect.ValueOf(field.value(now))
+			if v.Kind() == reflect.Ptr {
+				ptr := reflect.New(v.Type().Elem())
+				ptr.Elem().Set(value.Convert(v.Type().Elem()))
+				v.Set(ptr)
+			} else {
+				v.Set(value.Convert(v.Type()))
+			}
+		}
+	}
+}
+
+// 修改时为 Model 指定或注册的模型的 autoUpdateTime 字段设置当前时间，data 中已包含该字段时不覆盖
+func (session *Session) setUpdateTimes(data map[string]interface{}) map[string]interface{} {
+	var schema *modelSchema
+	if session.model != nil {
+		schema = parseSchema(reflect.TypeOf(session.model))
+	}
+	if schema == nil {
+		schema = session.tableSchema()
+	}
+	if schema == nil {
+		return data
+	}
+
+	var now time.Time
+	for _, field := range schema.autoTimes {
+		if !field.update {
+			continue
+		}
+		if _, ok := data[field.column]; ok {
+			continue
+		}
+		if now.IsZero() {
+			now = session.db.now()
+			copied := make(map[string]interface{}, len(data)+1)
+			for k, v := range data {
+				copied[k] = v
+			}
+			data = copied
+		}
+		data[field.column] = field.value(now)
+	}
+	return data
+}
+
+func hasAssignment(set clause.Set, column string) bool {
+	for _, assignment := range set.Assignments {
+		if assignment.Column.Name == column {
+			return true
+		}
+	}
+	return false
+}
+
+func isZeroValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	return reflect.ValueOf(value).IsZero()
+}
@@ -0,0 +1,108 @@
+package sqldb_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/clause"
+	"github.com/binwen/sqldb/tests"
+)
+
+type timestampAudit struct {
+	CheckedAt int64 `db:"checked_at,autoUpdateTime"`
+}
+
+type timestampGroup struct {
+	timestampAudit
+	Id        int
+	Name      string    `db:"name"`
+	CreatedAt time.Time `db:"created_at,autoCreateTime"`
+	UpdatedAt int64     `db:"updated_at,autoUpdateTime=milli"`
+}
+
+func TestAutoTimestamps(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		if _, err := tests.DBEngine.Exec("CREATE TABLE timestamp_group (id INTEGER PRIMARY KEY, name VARCHAR(80) NOT NULL UNIQUE, created_at TIMESTAMP NOT NULL, updated_at BIGINT NOT NULL, checked_at BIGINT NOT NULL)"); err != nil {
+			t.Fatal(err)
+		}
+		defer tests.DBEngine.Exec("DROP TABLE timestamp_group")
+
+		now := time.Date(2020, 1, 2, 3, 4, 5, 6000000, time.UTC)
+		tests.DBEngine.SetNowFunc(func() time.Time { return now })
+		defer tests.DBEngine.SetNowFunc(nil)
+		defer sqldb.UnregisterModel("timestamp_group")
+		sqldb.RegisterModel("timestamp_group", timestampGroup{})
+
+		group := timestampGroup{Id: 1, Name: "created"}
+		if _, err := tests.DBEngine.Table("timestamp_group").Create(&group); err != nil {
+			t.Fatal(err)
+		}
+		if !group.CreatedAt.Equal(now) || group.UpdatedAt != now.UnixNano()/int64(time.Millisecond) || group.CheckedAt != now.Unix() {
+			t.Errorf("timestamps should be filled back, got `%+v`", group)
+		}
+
+		preset := now.Add(-time.Hour)
+		groups := []timestampGroup{{Id: 2, Name: "bulk", CreatedAt: preset}, {Id: 3, Name: "bulk2"}}
+		if _, err := tests.DBEngine.Table("timestamp_group").BulkCreate(groups); err != nil {
+			t.Fatal(err)
+		}
+		if !groups[0].CreatedAt.Equal(preset) || !groups[1].CreatedAt.Equal(now) {
+			t.Errorf("only zero timestamps should be filled, got `%+v`", groups)
+		}
+		if _, err := tests.DBEngine.Table("timestamp_group").Create(map[string]interface{}{"id": 4, "name": "map"}); err != nil {
+			t.Fatalf("registered model timestamps should be filled for map, got `%v`", err)
+		}
+
+		var result timestampGroup
+		if err := tests.DBEngine.Table("timestamp_group").Where("id = ?", 4).First(&result); err != nil {
+			t.Fatal(err)
+		} else if !result.CreatedAt.Equal(now) || result.CheckedAt != now.Unix() {
+			t.Errorf("stored timestamps not expected, got `%+v`", result)
+		}
+
+		now = now.Add(time.Minute)
+		if _, err := tests.DBEngine.Table("timestamp_group").Where("id = ?", 1).Update("name", "updated"); err != nil {
+			t.Fatal(err)
+		}
+		if err := tests.DBEngine.Table("timestamp_group").Where("id = ?", 1).First(&result); err != nil {
+			t.Fatal(err)
+		}
+		if !result.CreatedAt.Equal(now.Add(-time.Minute)) || result.UpdatedAt != now.UnixNano()/int64(time.Millisecond) || result.CheckedAt != now.Unix() {
+			t.Errorf("only update timestamps should change, got `%+v`", result)
+		}
+		if _, err := tests.DBEngine.Table("timestamp_group").Where("id = ?", 1).BulkUpdate(map[string]interface{}{"checked_at": 1}); err != nil {
+			t.Fatal(err)
+		}
+		if err := tests.DBEngine.Table("timestamp_group").Where("id = ?", 1).First(&result); err != nil || result.CheckedAt != 1 {
+			t.Errorf("explicit update value should be kept, got `%+v` `%v`", result, err)
+		}
+
+		sqldb.UnregisterModel("timestamp_group")
+		now = now.Add(time.Minute)
+		if _, err := tests.DBEngine.Table("timestamp_group").Model(&timestampGroup{}).Where("id = ?", 2).BulkUpdate(map[string]interface{}{"name": "modeled"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := tests.DBEngine.Table("timestamp_group").Where("id = ?", 2).First(&result); err != nil {
+			t.Fatal(err)
+		} else if result.UpdatedAt != now.UnixNano()/int64(time.Millisecond) || result.CheckedAt != now.Unix() {
+			t.Errorf("update timestamps of the session model should be filled, got `%+v`", result)
+		}
+		sqldb.RegisterModel("timestamp_group", timestampGroup{})
+
+		if tests.DBEngine.DriverName() != "mysql" {
+			now = now.Add(time.Minute)
+			if _, err := tests.DBEngine.Table("timestamp_group").AddClause(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "name"}},
+				DoUpdates: clause.Assignments(map[string]interface{}{"id": 5}),
+			}).Create(&timestampGroup{Id: 6, Name: "map"}); err != nil {
+				t.Fatal(err)
+			}
+			if err := tests.DBEngine.Table("timestamp_group").Where("name = ?", "map").First(&result); err != nil {
+				t.Fatal(err)
+			} else if result.Id != 5 || result.CheckedAt != now.Unix() || !result.CreatedAt.Equal(now.Add(-3*time.Minute)) {
+				t.Errorf("upsert should refresh update timestamps only, got `%+v`", result)
+			}
+		}
+	})
+}
@@ -0,0 +1,171 @@
+package sqldb
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/binwen/sqldb/clause"
+	"github.com/binwen/sqldb/dialects"
+)
+
+var joinRegexp = regexp.MustCompile(`(?is)^\s*(?:INNER\s+)?JOIN\s+(.+?)\s+ON\s+(.+?)\s*$`)
+
+// 子查询，将会话的查询语句构建到外层语句中，参数序号与外层语句连续
+type subQuery struct {
+	session *Session
+}
+
+func (query subQuery) Build(builder clause.Builder) {
+	var written bool
+	if hint := query.session.statement.Hint; hint != "" {
+		builder.WriteString(hint)
+	}
+	for _, name := range []string{"SELECT", "FROM", "WHERE", "GROUP BY", "ORDER BY", "LIMIT", "FOR"} {
+		c, ok := query.session.statement.Clauses[name]
+		if !ok {
+			continue
+		}
+		if written {
+			builder.WriteByte(' ')
+		}
+		written = true
+
+		if clauseBuilder, ok := clauseBuilderMapping[name]; ok {
+			clauseBuilder(c, builder)
+		} else {
+			c.Build(builder)
+		}
+	}
+}
+
+func (session *Session) joinStyle() clause.JoinStyle {
+	if styler, ok := session.statement.Dialector.(dialects.JoinStyler); ok {
+		return styler.JoinStyle()
+	}
+	return clause.SubqueryJoinStyle
+}
+
+// 多表修改、删除时的连接表，非 InlineJoinStyle 时将 Join 中的原始语句解析为表与 ON 条件
+func (session *Session) joinTables() (joins []clause.Join, style clause.JoinStyle, err error) {
+	from, _ := session.statement.Clauses["FROM"].Expression.(clause.From)
+	style = session.joinStyle()
+	if style == clause.InlineJoinStyle {
+		return from.Joins, style, nil
+	}
+
+	for _, join := range from.Joins {
+		if join.Expression == nil {
+			if join.Type != "" && join.Type != clause.InnerJoin || len(join.ON.Exprs) == 0 {
+				return nil, style, fmt.Errorf("multi-table statement only supports inner join with ON condition")
+			}
+			joins = append(joins, join)
+			continue
+		}
+
+		expr, ok := join.Expression.(clause.Expr)
+		matches := joinRegexp.FindStringSubmatch(expr.SQL)
+		if !ok || matches == nil {
+			return nil, style, fmt.Errorf("multi-table statement only supports `JOIN table ON condition`, got `%v`", join.Expression)
+		}
+
+		table := clause.Table{}
+		fields := strings.Fields(strings.NewReplacer("`", "", `"`, "").Replace(matches[1]))
+		switch {
+		case len(fields) == 1:
+			table.Name = fields[0]
+		case len(fields) == 2:
+			table.Name, table.Alias = fields[0], fields[1]
+		case len(fields) == 3 && strings.EqualFold(fields[1], "AS"):
+			table.Name, table.Alias = fields[0], fields[2]
+		default:
+			return nil, style, fmt.Errorf("invalid join table `%s`", matches[1])
+		}
+		joins = append(joins, clause.Join{
+			Table: table,
+			ON:    clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: matches[2], Vars: expr.Vars}}},
+		})
+	}
+	return joins, style, nil
+}
+
+func (session *Session) hasJoins() bool {
+	from, ok := session.statement.Clauses["FROM"].Expression.(clause.From)
+	return ok && len(from.Joins) > 0
+}
+
+func (session *Session) buildJoinUpdate(data map[string]interface{}) error {
+	joins, style, err := session.joinTables()
+	if err != nil {
+		return err
+	}
+
+	set := clause.Assignments(data)
+	for idx, assignment := range set.Assignments {
+		if i := strings.LastIndexByte(assignment.Column.Name, '.'); i > 0 {
+			set.Assignments[idx].Column = clause.Column{Table: assignment.Column.Name[:i], Name: assignment.Column.Name[i+1:]}
+		}
+	}
+
+	where, _ := session.statement.Clauses["WHERE"].Expression.(clause.Where)
+	session.statement.AddClause(clause.UpdateJoin{
+		Style: style, Table: session.statement.Tables[0], Joins: joins, Set: set, Where: where,
+	})
+	session.statement.Build("UPDATE", "RETURNING")
+	return nil
+}
+
+func (session *Session) buildJoinDelete() error {
+	joins, style, err := session.joinTables()
+	if err != nil {
+		return err
+	}
+
+	where, _ := session.statement.Clauses["WHERE"].Expression.(clause.Where)
+	session.statement.AddClause(clause.DeleteJoin{
+		Style: style, Table: session.statement.Tables[0], Joins: joins, Where: where,
+	})
+	session.statement.Build("DELETE", "RETURNING")
+	return nil
+}
+
+// 将 query 的查询结果插入当前表，columns 为插入的字段，为空时插入全部字段，返回受影响的行数，如:
+//
+//	db.Table("archived_user").InsertFromSelect([]string{"id", "username"},
+//		db.Table("auth_user").Select("id", "username").Where("age > ?", 60))
+func (session *Session) InsertFromSelect(columns []string, query *Session) (affected int64, err error) {
+	session = session.getInstance()
+	defer session.Clear()
+	if session.Error != nil {
+		return 0, session.Error
+	}
+
+	query = query.Clone()
+	query.prepareQuery()
+	if query.Error != nil {
+		return 0, query.Error
+	}
+
+	if _, ok := session.statement.Clauses["ON CONFLICT"]; ok {
+		if wherer, ok := session.statement.Dialector.(dialects.InsertSelectWherer); ok && wherer.InsertSelectNeedsWhere() {
+			if _, ok := query.statement.Clauses["WHERE"]; !ok {
+				query.statement.AddClause(clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "true"}}})
+			}
+		}
+	}
+
+	insert := clause.InsertSelect{Query: subQuery{session: query}}
+	for _, column := range columns {
+		insert.Columns = append(insert.Columns, clause.Column{Name: column})
+	}
+
+	session.statement.AddClauseIfNotExists(clause.Insert{Table: clause.Table{Name: session.statement.Tables[0].Name}})
+	session.statement.AddClause(insert)
+	session.statement.Build("INSERT", "VALUES", "ON CONFLICT")
+
+	result, err := session.execContext(session.statement.SQL.String(), session.statement.SQLVars...)
+	if err != nil {
+		return
+	}
+	return result.RowsAffected()
+}
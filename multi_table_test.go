@@ -0,0 +1,108 @@
+package sqldb_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/tests"
+)
+
+func TestInsertFromSelect(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3)
+		tests.InsertAuthGroup(1)
+
+		if affected, err := tests.DBEngine.Table("auth_group").InsertFromSelect(
+			[]string{"name"},
+			tests.DBEngine.Table("auth_user").Select("username").Where("id > ?", 1).Asc("id"),
+		); err != nil {
+			t.Fatal(err)
+		} else if affected != 2 {
+			t.Errorf("rows affected should be `2`, got `%v`", affected)
+		}
+
+		var names []string
+		if err := tests.DBEngine.Table("auth_group").Select("name").Where("id > ?", 1).Asc("id").Find(&names); err != nil {
+			t.Error(err)
+		} else if len(names) != 2 || names[0] != "user2" || names[1] != "user3" {
+			t.Errorf("group names should be `[user2 user3]`, got `%v`", names)
+		}
+
+		err := tests.DBEngine.Tx(func(db *sqldb.SqlDB) error {
+			statements, err := db.Table("auth_group").ToSQL(func(s *sqldb.Session) error {
+				_, err := s.InsertFromSelect([]string{"name"}, db.Table("auth_user").Hint("/*+hint*/").Select("username").ForUpdate())
+				return err
+			})
+			if err != nil {
+				return err
+			}
+			if tests.DBEngine.DriverName() == "mysql" && statements[0].SQL != "INSERT INTO `auth_group` (`name`) /*+hint*/SELECT `username` FROM `auth_user` FOR UPDATE" {
+				t.Errorf("sub query should keep hint and lock, got `%v`", statements[0].SQL)
+			} else if !strings.Contains(statements[0].SQL, "/*+hint*/SELECT") {
+				t.Errorf("sub query should keep hint, got `%v`", statements[0].SQL)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestUpdateDeleteWithJoin(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3, 4)
+		tests.InsertAuthGroup(1, 2)
+		tests.InsertUserGroup(1, 1)
+		tests.InsertUserGroup(2, 1)
+		tests.InsertUserGroup(3, 2)
+
+		if affected, err := tests.DBEngine.Table("auth_user").Join(
+			"JOIN auth_user_groups ug ON ug.user_id = auth_user.id",
+		).Where("ug.group_id = ?", 1).Or("auth_user.id = ?", 4).Update("age", 30); err != nil {
+			t.Fatal(err)
+		} else if affected != 2 {
+			t.Errorf("rows affected should be `2`, got `%v`", affected)
+		}
+
+		var ids []int
+		if err := tests.DBEngine.Table("auth_user").Select("id").Where("age = ?", 30).Asc("id").Find(&ids); err != nil {
+			t.Error(err)
+		} else if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+			t.Errorf("updated ids should be `[1 2]`, got `%v`", ids)
+		}
+
+		if affected, err := tests.DBEngine.Table("auth_user").Join(
+			"JOIN auth_user_groups ug ON ug.user_id = auth_user.id",
+		).Where("ug.group_id = ?", 2).Update("age", sqldb.Expr("ug.group_id * ?", 10)); err != nil {
+			t.Fatal(err)
+		} else if affected != 1 {
+			t.Errorf("rows affected should be `1`, got `%v`", affected)
+		}
+		var age int
+		if err := tests.DBEngine.Table("auth_user").Select("age").Where("id = ?", 3).Find(&age); err != nil || age != 20 {
+			t.Errorf("value from joined table should be assigned, got `%v` `%v`", age, err)
+		}
+
+		if affected, err := tests.DBEngine.Table("auth_user").Join(
+			"INNER JOIN auth_user_groups AS ug ON ug.user_id = auth_user.id",
+		).Where("ug.group_id = ?", 2).Delete(); err != nil {
+			t.Fatal(err)
+		} else if affected != 1 {
+			t.Errorf("rows affected should be `1`, got `%v`", affected)
+		}
+		if count, _ := tests.DBEngine.Table("auth_user").Count(); count != 3 {
+			t.Errorf("should remain `3` users, got `%v`", count)
+		}
+		if count, _ := tests.DBEngine.Table("auth_user_groups").Count(); count != 3 {
+			t.Errorf("joined table should not be deleted, got `%v` rows", count)
+		}
+
+		if _, err := tests.DBEngine.Table("auth_user").Join(
+			"LEFT JOIN auth_user_groups ug ON ug.user_id = auth_user.id",
+		).Where("ug.id IS NULL").Delete(); err == nil && tests.DBEngine.DriverName() != "mysql" {
+			t.Error("left join delete should return error")
+		}
+	})
+}
@@ -0,0 +1,79 @@
+package sqldb
+
+import (
+	"reflect"
+
+	"github.com/binwen/sqldb/clause"
+)
+
+// Model 设置的模型声明了 version 字段时返回该字段及其列名
+func (session *Session) versionField() (reflect.Value, clause.Column, bool) {
+	if session.model == nil || len(session.statement.Tables) == 0 {
+		return reflect.Value{}, clause.Column{}, false
+	}
+	model := reflect.Indirect(reflect.ValueOf(session.model))
+	if model.Kind() != reflect.Struct {
+		return reflect.Value{}, clause.Column{}, false
+	}
+	schema := parseSchema(model.Type())
+	if schema == nil || schema.version == nil {
+		return reflect.Value{}, clause.Column{}, false
+	}
+
+	table := session.statement.Tables[0]
+	column := clause.Column{Table: table.Name, Name: schema.version.column}
+	if table.Alias != "" {
+		column.Table = table.Alias
+	}
+	return model.FieldByIndex(schema.version.index), column, true
+}
+
+// 乐观锁修改：模型声明了 version 字段时追加 WHERE version = 当前版本 并将版本加 1，
+// 未修改任何行时返回 ErrStaleObject，成功时回写模型中的版本；data 中已设置版本时不自动加 1，如:
+//
+//	db.Table("article").Model(&article).Where("id = ?", article.Id).Update("title", "new")
+func (session *Session) versionedUpdate(data map[string]interface{}) (int64, error) {
+	field, column, ok := session.versionField()
+	if !ok {
+		return session.update(data)
+	}
+	if _, ok := session.statement.Clauses["WHERE"]; !ok {
+		return 0, ErrMissingWhereClause
+	}
+
+	var current interface{}
+	switch field.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		current = field.Uint()
+	default:
+		current = field.Int()
+	}
+
+	copied := make(map[string]interface{}, len(data)+1)
+	for k, v := range data {
+		copied[k] = v
+	}
+	_, explicit := copied[column.Name]
+	if !explicit {
+		copied[column.Name] = clause.Expr{SQL: session.statement.Quote(column) + " + 1"}
+	}
+	session.statement.groupWhere()
+	session.statement.AddClause(clause.Where{Exprs: []clause.Expression{clause.EQ{Column: column, Value: current}}})
+
+	affected, err := session.update(copied)
+	if err != nil {
+		return affected, err
+	}
+	if affected == 0 {
+		return 0, ErrStaleObject
+	}
+	if !explicit && field.CanSet() {
+		switch field.Kind() {
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			field.SetUint(field.Uint() + 1)
+		default:
+			field.SetInt(field.Int() + 1)
+		}
+	}
+	return affected, nil
+}
@@ -0,0 +1,88 @@
+package sqldb_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/tests"
+)
+
+type versionedArticle struct {
+	Id      int
+	Title   string `db:"title"`
+	Version int    `db:"version,version"`
+}
+
+func TestOptimisticLock(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		if _, err := tests.DBEngine.Exec("CREATE TABLE versioned_article (id INTEGER PRIMARY KEY, title VARCHAR(80) NOT NULL, version INTEGER NOT NULL, deleted_at TIMESTAMP NULL)"); err != nil {
+			t.Fatal(err)
+		}
+		defer tests.DBEngine.Exec("DROP TABLE versioned_article")
+
+		if _, err := tests.DBEngine.Table("versioned_article").Create(&versionedArticle{Id: 1, Title: "draft", Version: 1}); err != nil {
+			t.Fatal(err)
+		}
+
+		var first, second versionedArticle
+		for _, article := range []*versionedArticle{&first, &second} {
+			if err := tests.DBEngine.Table("versioned_article").Where("id = ?", 1).First(article); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := tests.DBEngine.Table("versioned_article").Model(&first).Where("id = ?", 1).Update("title", "first"); err != nil {
+			t.Fatal(err)
+		}
+		if first.Version != 2 {
+			t.Errorf("model version should be increased, got `%v`", first.Version)
+		}
+
+		_, err := tests.DBEngine.Table("versioned_article").Model(&second).Where("id = ?", 1).Update("title", "second")
+		if !errors.Is(err, sqldb.ErrStaleObject) {
+			t.Errorf("stale update should return ErrStaleObject, got `%v`", err)
+		}
+		if second.Version != 1 {
+			t.Errorf("stale model version should be kept, got `%v`", second.Version)
+		}
+
+		var result versionedArticle
+		if err := tests.DBEngine.Table("versioned_article").Where("id = ?", 1).First(&result); err != nil {
+			t.Fatal(err)
+		} else if result.Title != "first" || result.Version != 2 {
+			t.Errorf("only the first update should be applied, got `%+v`", result)
+		}
+
+		if _, err := tests.DBEngine.Table("versioned_article").Create(&versionedArticle{Id: 2, Title: "other", Version: 5}); err != nil {
+			t.Fatal(err)
+		}
+		stale := versionedArticle{Id: 1, Version: 1}
+		if _, err := tests.DBEngine.Table("versioned_article").Model(&stale).Where("id = ?", 2).Or("id = ?", 1).Update("title", "stale"); !errors.Is(err, sqldb.ErrStaleObject) {
+			t.Errorf("version should apply to every OR branch, got `%v`", err)
+		}
+		if err := tests.DBEngine.Table("versioned_article").Where("id = ?", 2).First(&result); err != nil {
+			t.Fatal(err)
+		} else if result.Title != "other" || result.Version != 5 {
+			t.Errorf("row with another version should not be updated, got `%+v`", result)
+		}
+
+		if _, err := tests.DBEngine.Table("versioned_article").Model(&first).Update("title", "all"); err != sqldb.ErrMissingWhereClause {
+			t.Errorf("versioned update without where should be rejected, got `%v`", err)
+		}
+		if _, err := tests.DBEngine.Table("versioned_article").Where("id = ?", 1).Update("title", "unversioned"); err != nil {
+			t.Errorf("update without model should not be versioned, got `%v`", err)
+		}
+
+		defer sqldb.UnregisterSoftDelete("versioned_article")
+		sqldb.RegisterSoftDelete("versioned_article")
+		if affected, err := tests.DBEngine.Table("versioned_article").Model(&second).Where("id = ?", 1).Delete(); err != nil || affected != 1 {
+			t.Errorf("soft delete should not be versioned, got `%v` `%v`", affected, err)
+		}
+		if err := tests.DBEngine.Table("versioned_article").Unscoped().Where("id = ?", 1).First(&result); err != nil {
+			t.Fatal(err)
+		} else if result.Version != 2 || second.Version != 1 {
+			t.Errorf("soft delete should not change versions, got `%v` `%v`", result.Version, second.Version)
+		}
+	})
+}
@@ -0,0 +1,209 @@
+// 事务性发件箱：在业务事务中记录领域事件，由 relay 轮询并投递，同一聚合的事件按记录顺序投递
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/clause"
+	"github.com/binwen/sqldb/internal/jobs"
+)
+
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+)
+
+// 待记录的事件
+type Message struct {
+	AggregateKey string // 聚合标识，同一聚合的事件按记录顺序投递
+	Topic        string
+	Payload      []byte
+}
+
+// 发件箱中的事件，时间均为毫秒时间戳
+type Event struct {
+	ID            int64  `db:"id"`
+	AggregateKey  string `db:"aggregate_key"`
+	Topic         string `db:"topic"`
+	Payload       []byte `db:"payload"`
+	Status        string `db:"status"`
+	Attempts      int    `db:"attempts"`
+	NextAttemptAt int64  `db:"next_attempt_at"`
+	LastError     string `db:"last_error"`
+	CreatedAt     int64  `db:"created_at"`
+	SentAt        int64  `db:"sent_at"`
+}
+
+// 投递一批事件，返回错误时整批事件按退避时间重试
+type Publisher func(ctx context.Context, events []*Event) error
+
+type Options struct {
+	Table   string                           // 发件箱表名，默认为 sqldb_outbox
+	Backoff func(attempts int) time.Duration // 投递失败后的重试间隔，默认从 1 秒开始指数增长，最长 10 分钟
+	Clock   func() time.Time                 // 时钟，默认使用数据库的时钟(SetNowFunc)，可在测试中替换
+}
+
+type RelayOptions struct {
+	BatchSize    int           // 每批投递的事件数，默认 100
+	PollInterval time.Duration // 没有待投递事件时的轮询间隔，默认 1 秒
+	OnError      func(error)   // 投递或读写发件箱出错时的回调
+}
+
+type Outbox struct {
+	db   *sqldb.EngineGroup
+	opts Options
+}
+
+func New(db *sqldb.EngineGroup, opts ...Options) *Outbox {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Table == "" {
+		opt.Table = "sqldb_outbox"
+	}
+	if opt.Backoff == nil {
+		opt.Backoff = DefaultBackoff
+	}
+	opt.Clock = jobs.Clock(db, opt.Clock)
+	return &Outbox{db: db, opts: opt}
+}
+
+// 默认重试间隔: 1s、2s、4s ... 最长 10 分钟
+func DefaultBackoff(attempts int) time.Duration {
+	return jobs.Backoff(attempts, 10*time.Minute)
+}
+
+func (o *Outbox) now() int64 {
+	return jobs.Millis(o.opts.Clock())
+}
+
+// 创建发件箱表及索引
+func (o *Outbox) Migrate(ctx context.Context) error {
+	return jobs.Migrate(ctx, o.db, o.opts.Table, `  id %s,
+  aggregate_key VARCHAR(191) NOT NULL,
+  topic VARCHAR(191) NOT NULL,
+  payload %s,
+  status VARCHAR(16) NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  next_attempt_at BIGINT NOT NULL,
+  last_error TEXT NOT NULL,
+  created_at BIGINT NOT NULL,
+  sent_at BIGINT NOT NULL DEFAULT 0`, map[string]string{"relay": "status, next_attempt_at", "aggregate": "aggregate_key, status"})
+}
+
+// 在业务事务 tx 中记录事件，事件随事务一起提交或回滚，如:
+//
+//	db.Tx(func(tx *sqldb.SqlDB) error {
+//		if _, err := tx.Table("orders").Create(order); err != nil {
+//			return err
+//		}
+//		return box.Record(ctx, tx, outbox.Message{AggregateKey: "order:1", Topic: "order.created", Payload: data})
+//	})
+func (o *Outbox) Record(ctx context.Context, tx *sqldb.SqlDB, messages ...Message) error {
+	now := o.now()
+	for _, message := range messages {
+		if _, err := tx.TableContext(ctx, o.opts.Table).Create(map[string]interface{}{
+			"aggregate_key":   message.AggregateKey,
+			"topic":           message.Topic,
+			"payload":         message.Payload,
+			"status":          StatusPending,
+			"next_attempt_at": now,
+			"last_error":      "",
+			"created_at":      now,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 加锁读取一批待投递的事件交给 publisher，成功时标记为已发送，失败时按退避时间重试，返回成功投递的事件数；
+// 同一聚合中存在等待重试的更早事件时，后续事件不会被投递
+func (o *Outbox) RelayOnce(ctx context.Context, publisher Publisher, batchSize int) (sent int, err error) {
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+
+	var publishErr error
+	err = o.db.TxContext(ctx, func(tx *sqldb.SqlDB) error {
+		now := o.now()
+		var events []*Event
+		if err := tx.TableContext(ctx, o.opts.Table).
+			Where("status = ? AND next_attempt_at <= ?", StatusPending, now).
+			Where(fmt.Sprintf(
+				"NOT EXISTS (SELECT 1 FROM %s AS blocker WHERE blocker.aggregate_key = %s.aggregate_key AND blocker.status = ? AND blocker.id < %s.id AND blocker.next_attempt_at > ?)",
+				o.opts.Table, o.opts.Table, o.opts.Table,
+			), StatusPending, now).
+			Asc("id").Limit(batchSize).ForUpdate().Find(&events); err != nil || len(events) == 0 {
+			return err
+		}
+
+		ids := make([]int64, len(events))
+		for idx, event := range events {
+			ids[idx] = event.ID
+		}
+
+		if publishErr = publisher(ctx, events); publishErr == nil {
+			sent = len(events)
+			_, err := tx.TableContext(ctx, o.opts.Table).Where("id IN (?)", ids).BulkUpdate(map[string]interface{}{
+				"status": StatusSent, "sent_at": o.now(), "last_error": "",
+			})
+			return err
+		}
+
+		for _, event := range events {
+			if _, err := tx.TableContext(ctx, o.opts.Table).Where("id = ?", event.ID).BulkUpdate(map[string]interface{}{
+				"attempts":        clause.Expr{SQL: "attempts + 1"},
+				"next_attempt_at": now + int64(o.opts.Backoff(event.Attempts+1)/time.Millisecond),
+				"last_error":      publishErr.Error(),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return sent, publishErr
+}
+
+// 持续投递事件，阻塞直到 ctx 取消
+func (o *Outbox) Relay(ctx context.Context, publisher Publisher, opts ...RelayOptions) error {
+	var opt RelayOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.BatchSize <= 0 {
+		opt.BatchSize = 100
+	}
+	if opt.PollInterval <= 0 {
+		opt.PollInterval = time.Second
+	}
+
+	for {
+		sent, err := o.RelayOnce(ctx, publisher, opt.BatchSize)
+		if err != nil && opt.OnError != nil && ctx.Err() == nil {
+			opt.OnError(err)
+		}
+		if sent == opt.BatchSize && err == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(opt.PollInterval):
+		}
+	}
+}
+
+// 删除发送时间早于 before 的已发送事件，返回删除的行数
+func (o *Outbox) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return o.db.TableContext(ctx, o.opts.Table).
+		Where("status = ? AND sent_at < ?", StatusSent, jobs.Millis(before)).Delete()
+}
@@ -0,0 +1,187 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/outbox"
+	"github.com/binwen/sqldb/tests"
+)
+
+func newOutbox(t *testing.T, opts outbox.Options) *outbox.Outbox {
+	opts.Table = "sqldb_test_outbox"
+	box := outbox.New(tests.DBEngine, opts)
+	tests.MigrateTable(t, opts.Table, box.Migrate)
+	return box
+}
+
+func topics(events []*outbox.Event) (result []string) {
+	for _, event := range events {
+		result = append(result, event.AggregateKey+":"+event.Topic)
+	}
+	return
+}
+
+func TestRecord(t *testing.T) {
+	ctx := context.Background()
+	box := newOutbox(t, outbox.Options{})
+
+	rollback := errors.New("rollback")
+	if err := tests.DBEngine.Tx(func(tx *sqldb.SqlDB) error {
+		if err := box.Record(ctx, tx, outbox.Message{AggregateKey: "order:1", Topic: "created"}); err != nil {
+			return err
+		}
+		return rollback
+	}); err != rollback {
+		t.Fatal(err)
+	}
+	if err := tests.DBEngine.Tx(func(tx *sqldb.SqlDB) error {
+		return box.Record(ctx, tx,
+			outbox.Message{AggregateKey: "order:2", Topic: "created", Payload: []byte(`{"id":2}`)},
+			outbox.Message{AggregateKey: "order:2", Topic: "paid"},
+		)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	var published []*outbox.Event
+	sent, err := box.RelayOnce(ctx, func(ctx context.Context, events []*outbox.Event) error {
+		published = append(published, events...)
+		return nil
+	}, 10)
+	if err != nil || sent != 2 {
+		t.Fatalf("committed events should be relayed, got %d `%v`", sent, err)
+	}
+	if got := topics(published); len(got) != 2 || got[0] != "order:2:created" || got[1] != "order:2:paid" {
+		t.Errorf("only committed events should be relayed in order, got `%v`", got)
+	}
+	if string(published[0].Payload) != `{"id":2}` {
+		t.Errorf("payload should be kept, got `%s`", published[0].Payload)
+	}
+
+	if sent, err := box.RelayOnce(ctx, func(ctx context.Context, events []*outbox.Event) error {
+		t.Errorf("sent events should not be relayed again, got `%v`", topics(events))
+		return nil
+	}, 10); err != nil || sent != 0 {
+		t.Errorf("nothing should be relayed, got %d `%v`", sent, err)
+	}
+
+	if purged, err := box.Purge(ctx, time.Now().Add(time.Minute)); err != nil || purged != 2 {
+		t.Errorf("sent events should be purged, got %d `%v`", purged, err)
+	}
+}
+
+func TestRelayRetryKeepsAggregateOrder(t *testing.T) {
+	ctx := context.Background()
+	c := tests.NewClock(time.Unix(1600000000, 0))
+	box := newOutbox(t, outbox.Options{Clock: c.Now, Backoff: func(attempts int) time.Duration {
+		return time.Duration(attempts) * time.Minute
+	}})
+
+	if err := tests.DBEngine.Tx(func(tx *sqldb.SqlDB) error {
+		return box.Record(ctx, tx,
+			outbox.Message{AggregateKey: "a", Topic: "1"},
+			outbox.Message{AggregateKey: "b", Topic: "1"},
+			outbox.Message{AggregateKey: "a", Topic: "2"},
+		)
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	failure := errors.New("broker unavailable")
+	if sent, err := box.RelayOnce(ctx, func(ctx context.Context, events []*outbox.Event) error {
+		return failure
+	}, 1); err != failure || sent != 0 {
+		t.Fatalf("publisher error should be returned, got %d `%v`", sent, err)
+	}
+
+	var published []*outbox.Event
+	publish := func(ctx context.Context, events []*outbox.Event) error {
+		published = append(published, events...)
+		return nil
+	}
+	if _, err := box.RelayOnce(ctx, publish, 10); err != nil {
+		t.Fatal(err)
+	}
+	if got := topics(published); len(got) != 1 || got[0] != "b:1" {
+		t.Errorf("events behind a retrying event of the same aggregate should wait, got `%v`", got)
+	}
+
+	c.Add(time.Minute)
+	published = nil
+	if _, err := box.RelayOnce(ctx, publish, 10); err != nil {
+		t.Fatal(err)
+	}
+	if got := topics(published); len(got) != 2 || got[0] != "a:1" || got[1] != "a:2" {
+		t.Errorf("aggregate events should be relayed in order after backoff, got `%v`", got)
+	}
+	if published[0].Attempts != 1 || published[0].LastError != failure.Error() {
+		t.Errorf("failed attempt should be recorded, got `%+v`", published[0])
+	}
+}
+
+func TestRelay(t *testing.T) {
+	box := newOutbox(t, outbox.Options{})
+	if err := tests.DBEngine.Tx(func(tx *sqldb.SqlDB) error {
+		for i := 0; i < 5; i++ {
+			if err := box.Record(context.Background(), tx, outbox.Message{AggregateKey: "order", Topic: "event"}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var count int
+	done := make(chan error)
+	go func() {
+		done <- box.Relay(ctx, func(ctx context.Context, events []*outbox.Event) error {
+			if count += len(events); count == 5 {
+				cancel()
+			}
+			return nil
+		}, outbox.RelayOptions{BatchSize: 2, PollInterval: 10 * time.Millisecond})
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(5 * time.Second):
+		cancel()
+		t.Fatal("relay did not finish in time")
+	}
+	if count != 5 {
+		t.Errorf("all events should be relayed, got %d", count)
+	}
+}
+
+func TestEngineClock(t *testing.T) {
+	ctx := context.Background()
+	c := tests.NewClock(time.Unix(1600000000, 0))
+	tests.DBEngine.SetNowFunc(c.Now)
+	defer tests.DBEngine.SetNowFunc(nil)
+	box := newOutbox(t, outbox.Options{})
+
+	if err := tests.DBEngine.Tx(func(tx *sqldb.SqlDB) error {
+		return box.Record(ctx, tx, outbox.Message{AggregateKey: "order:1", Topic: "created"})
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var published []*outbox.Event
+	if _, err := box.RelayOnce(ctx, func(ctx context.Context, events []*outbox.Event) error {
+		published = append(published, events...)
+		return nil
+	}, 10); err != nil {
+		t.Fatal(err)
+	}
+	if len(published) != 1 || published[0].CreatedAt != 1600000000000 {
+		t.Errorf("outbox should default to the engine clock, got `%+v`", published)
+	}
+}
@@ -0,0 +1,341 @@
+package sqldb
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/binwen/sqldb/clause"
+)
+
+// 游标签名密钥，默认进程启动时随机生成；多实例部署时需通过 SetCursorSecret 设置相同的密钥
+var cursorSecret = func() []byte {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		panic(fmt.Sprintf("failed to generate cursor secret, got error %v", err))
+	}
+	return secret
+}()
+
+func SetCursorSecret(secret []byte) {
+	cursorSecret = secret
+}
+
+type Page struct {
+	Page       int
+	Size       int
+	Total      int64
+	TotalPages int
+}
+
+type CursorPage struct {
+	Size       int
+	HasMore    bool
+	NextCursor string
+}
+
+// 统计总数，忽略 ORDER BY 与 LIMIT；带 GROUP BY 或 DISTINCT 时以子查询统计
+func (session *Session) countAll() (count int64, err error) {
+	defer session.Clear()
+	if session.Error != nil {
+		return count, session.Error
+	}
+
+	delete(session.statement.Clauses, "ORDER BY")
+	delete(session.statement.Clauses, "LIMIT")
+
+	_, hasGroupBy := session.statement.Clauses["GROUP BY"]
+	s, _ := session.statement.Clauses["SELECT"].Expression.(clause.Select)
+	if hasGroupBy || s.Distinct {
+		if err := session.buildQuerySQL(); err != nil {
+			return count, err
+		}
+		query := "SELECT count(*) FROM (" + session.statement.SQL.String() + ") AS " + session.statement.Quote("sub_query")
+		err = session.queryRowScan(query, session.statement.SQLVars, &count)
+		return count, err
+	}
+
+	delete(session.statement.Clauses, "SELECT")
+	session.statement.AddClause(clause.Select{Expressions: []clause.Expression{clause.Expr{SQL: "count(*)"}}})
+	if err := session.buildQuerySQL(); err != nil {
+		return count, err
+	}
+	err = session.queryRowScan(session.statement.SQL.String(), session.statement.SQLVars, &count)
+	return count, err
+}
+
+// 分页查询，page 从1开始，返回分页信息及总数
+func (session *Session) Paginate(page, size int, dest interface{}) (*Page, error) {
+	session = session.getInstance()
+	if size <= 0 {
+		session.Clear()
+		return nil, fmt.Errorf("page size must be greater than 0, got %d", size)
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	total, err := session.Clone().countAll()
+	if err != nil && err != ErrDryRun {
+		session.Clear()
+		return nil, err
+	}
+
+	result := &Page{Page: page, Size: size, Total: total, TotalPages: int((total + int64(size) - 1) / int64(size))}
+	if session.dryRun == nil && int64((page-1)*size) >= total {
+		session.Clear()
+		return result, nil
+	}
+
+	return result, session.Limit(size).Offset((page - 1) * size).Find(dest)
+}
+
+type pageCursor struct {
+	columns []string
+	desc    bool
+	values  []interface{}
+}
+
+type cursorPayload struct {
+	Columns []string          `json:"c"`
+	Desc    bool              `json:"d"`
+	Values  []json.RawMessage `json:"v"`
+}
+
+type cursorTime struct {
+	Time time.Time `json:"time"`
+}
+
+func signCursor(payload []byte) []byte {
+	mac := hmac.New(sha256.New, cursorSecret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+func EncodeCursor(columns []string, desc bool, values []interface{}) (string, error) {
+	payload := cursorPayload{Columns: columns, Desc: desc}
+	for _, value := range values {
+		if v, ok := value.(time.Time); ok {
+			value = cursorTime{Time: v}
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		payload.Values = append(payload.Values, raw)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(data) + "." + base64.RawURLEncoding.EncodeToString(signCursor(data)), nil
+}
+
+func decodeCursor(cursor string) (*pageCursor, error) {
+	parts := strings.Split(cursor, ".")
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, signCursor(data)) {
+		return nil, ErrInvalidCursor
+	}
+
+	var payload cursorPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	pc := &pageCursor{columns: payload.Columns, desc: payload.Desc}
+	for _, raw := range payload.Values {
+		var t cursorTime
+		if err := json.Unmarshal(raw, &t); err == nil && !t.Time.IsZero() {
+			pc.values = append(pc.values, t.Time)
+			continue
+		}
+
+		decoder := json.NewDecoder(strings.NewReader(string(raw)))
+		decoder.UseNumber()
+		var value interface{}
+		if err := decoder.Decode(&value); err != nil {
+			return nil, ErrInvalidCursor
+		}
+		if number, ok := value.(json.Number); ok {
+			if i, err := number.Int64(); err == nil {
+				value = i
+			} else if f, err := number.Float64(); err == nil {
+				value = f
+			}
+		}
+		pc.values = append(pc.values, value)
+	}
+
+	return pc, nil
+}
+
+// 设置游标分页的起始位置，cursor 为上一页返回的 NextCursor，空字符串表示第一页
+func (session *Session) After(cursor string) *Session {
+	session = session.getInstance()
+	if cursor == "" {
+		return session
+	}
+
+	pc, err := decodeCursor(cursor)
+	if err != nil {
+		session.AddError(err)
+		return session
+	}
+	session.cursor = pc
+	return session
+}
+
+// 从 ORDER BY 子句中解析游标分页使用的排序字段，所有字段排序方向必须一致
+func (session *Session) cursorColumns() (columns []string, desc bool, err error) {
+	orderBy, ok := session.statement.Clauses["ORDER BY"].Expression.(clause.OrderBy)
+	if !ok || len(orderBy.Columns) == 0 {
+		return nil, false, errors.New("cursor pagination requires ORDER BY columns")
+	}
+
+	for idx, column := range orderBy.Columns {
+		name, isDesc := column.Column.Name, column.Desc
+		if column.Column.Raw {
+			fields := strings.Fields(name)
+			if len(fields) == 0 || len(fields) > 2 {
+				return nil, false, fmt.Errorf("unsupported cursor order column `%s`", name)
+			}
+			name = fields[0]
+			if len(fields) == 2 {
+				isDesc = strings.ToUpper(fields[1]) == "DESC"
+			}
+		}
+
+		if idx == 0 {
+			desc = isDesc
+		} else if desc != isDesc {
+			return nil, false, errors.New("cursor pagination requires all ORDER BY columns in the same direction")
+		}
+		columns = append(columns, name)
+	}
+
+	return columns, desc, nil
+}
+
+func cursorValues(row reflect.Value, columns []string) ([]interface{}, error) {
+	row = reflect.Indirect(row)
+	values := make([]interface{}, 0, len(columns))
+	for _, column := range columns {
+		if idx := strings.LastIndex(column, "."); idx != -1 {
+			column = column[idx+1:]
+		}
+
+		var value reflect.Value
+		switch row.Kind() {
+		case reflect.Map:
+			value = row.MapIndex(reflect.ValueOf(column))
+		case reflect.Struct:
+			value = mapper.FieldMap(row)[column]
+		}
+		if !value.IsValid() {
+			return nil, fmt.Errorf("cursor column `%s` not found in scan destination", column)
+		}
+
+		v := reflect.Indirect(value).Interface()
+		if valuer, ok := v.(driver.Valuer); ok {
+			var err error
+			if v, err = valuer.Value(); err != nil {
+				return nil, err
+			}
+		}
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+// 游标(keyset)分页查询，通过 After 设置起始游标，ORDER BY 字段组合必须唯一
+func (session *Session) CursorPaginate(size int, dest interface{}) (*CursorPage, error) {
+	session = session.getInstance()
+	if size <= 0 {
+		session.Clear()
+		return nil, fmt.Errorf("page size must be greater than 0, got %d", size)
+	}
+
+	destRefValue := reflect.ValueOf(dest)
+	if destRefValue.Kind() != reflect.Ptr || destRefValue.Elem().Kind() != reflect.Slice {
+		session.Clear()
+		return nil, fmt.Errorf("cursor pagination destination must be a pointer to slice, got %T", dest)
+	}
+
+	columns, desc, err := session.cursorColumns()
+	if err != nil {
+		session.Clear()
+		return nil, err
+	}
+
+	if session.cursor != nil {
+		values := session.cursor.values
+		if session.cursor.desc != desc || strings.Join(session.cursor.columns, ",") != strings.Join(columns, ",") {
+			session.Clear()
+			return nil, ErrInvalidCursor
+		}
+
+		var left, right strings.Builder
+		for idx, column := range columns {
+			if idx > 0 {
+				left.WriteByte(',')
+				right.WriteByte(',')
+			}
+			session.statement.QuoteTo(&left, parseColumn(column))
+			right.WriteByte('?')
+		}
+
+		op := " > "
+		if desc {
+			op = " < "
+		}
+		session.statement.groupWhere()
+		if len(columns) == 1 {
+			session = session.Where(left.String()+op+right.String(), values...)
+		} else {
+			session = session.Where("("+left.String()+")"+op+"("+right.String()+")", values...)
+		}
+	}
+
+	if err := session.Limit(size + 1).Find(dest); err != nil {
+		return nil, err
+	}
+
+	result := &CursorPage{Size: size}
+	rows := destRefValue.Elem()
+	if rows.Len() > size {
+		result.HasMore = true
+		rows.Set(rows.Slice(0, size))
+	}
+
+	if result.HasMore {
+		values, err := cursorValues(rows.Index(rows.Len()-1), columns)
+		if err != nil {
+			return nil, err
+		}
+		if result.NextCursor, err = EncodeCursor(columns, desc, values); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
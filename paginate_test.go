@@ -0,0 +1,125 @@
+package sqldb_test
+
+import (
+	"testing"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/tests"
+)
+
+func TestPaginate(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3, 4, 5, 6, 7)
+		var users []tests.SimpleAuthUser
+		if page, err := tests.DBEngine.Table("auth_user").Select("id, username").Where("id > ?", 1).Asc("id").Paginate(2, 4, &users); err != nil {
+			t.Error(err)
+		} else {
+			if page.Total != 6 || page.TotalPages != 2 {
+				t.Errorf("page total should be `6` of `2` pages, got `%v` of `%v` pages", page.Total, page.TotalPages)
+			}
+			if len(users) != 2 || users[0].Id != 6 {
+				t.Errorf("second page should be users `[6 7]`, got `%v`", users)
+			}
+		}
+
+		var empty []tests.SimpleAuthUser
+		if page, err := tests.DBEngine.Table("auth_user").Paginate(3, 4, &empty); err != nil {
+			t.Error(err)
+		} else if page.Total != 7 || len(empty) != 0 {
+			t.Errorf("out of range page should be empty with total `7`, got `%v` rows with total `%v`", len(empty), page.Total)
+		}
+
+		var groups []map[string]interface{}
+		if page, err := tests.DBEngine.Table("auth_user").Select("age").GroupBy("age").Paginate(1, 10, &groups); err != nil {
+			t.Error(err)
+		} else if page.Total != 1 || len(groups) != 1 {
+			t.Errorf("grouped page total should be `1`, got `%v`", page.Total)
+		}
+	})
+}
+
+func TestCursorPaginate(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3, 4, 5)
+		var ids []int
+		cursor := ""
+		for {
+			var users []tests.AuthUser
+			page, err := tests.DBEngine.Table("auth_user").Asc("age", "id").After(cursor).CursorPaginate(2, &users)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, user := range users {
+				ids = append(ids, user.Id)
+			}
+			if !page.HasMore {
+				break
+			}
+			cursor = page.NextCursor
+		}
+		if len(ids) != 5 || ids[0] != 1 || ids[4] != 5 {
+			t.Errorf("cursor pagination should walk ids `[1 2 3 4 5]`, got `%v`", ids)
+		}
+
+		ids = nil
+		cursor = ""
+		base := tests.DBEngine.Table("auth_user").Asc("id").Immutable()
+		for i := 0; i < 5; i++ {
+			var users []tests.SimpleAuthUser
+			page, err := base.After(cursor).CursorPaginate(2, &users)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, user := range users {
+				ids = append(ids, user.Id)
+			}
+			if !page.HasMore {
+				break
+			}
+			cursor = page.NextCursor
+		}
+		if len(ids) != 5 || ids[2] != 3 || ids[4] != 5 {
+			t.Errorf("cursor pagination on immutable session should walk ids `[1 2 3 4 5]`, got `%v`", ids)
+		}
+
+		ids = nil
+		cursor = ""
+		for i := 0; i < 5; i++ {
+			var users []tests.SimpleAuthUser
+			page, err := tests.DBEngine.Table("auth_user").Where("id = ?", 1).Or("id > ?", 3).Asc("id").After(cursor).CursorPaginate(2, &users)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, user := range users {
+				ids = append(ids, user.Id)
+			}
+			if !page.HasMore {
+				break
+			}
+			cursor = page.NextCursor
+		}
+		if len(ids) != 3 || ids[0] != 1 || ids[1] != 4 || ids[2] != 5 {
+			t.Errorf("cursor should apply to every OR branch and walk ids `[1 4 5]`, got `%v`", ids)
+		}
+
+		var users []map[string]interface{}
+		page, err := tests.DBEngine.Table("auth_user").Desc("id").CursorPaginate(3, &users)
+		if err != nil {
+			t.Fatal(err)
+		}
+		users = nil
+		if _, err := tests.DBEngine.Table("auth_user").Desc("id").After(page.NextCursor).CursorPaginate(3, &users); err != nil {
+			t.Error(err)
+		} else if len(users) != 2 || users[0]["id"].(int64) != 2 {
+			t.Errorf("descending second page should be ids `[2 1]`, got `%v`", users)
+		}
+
+		if _, err := tests.DBEngine.Table("auth_user").Desc("id").After(page.NextCursor+"x").CursorPaginate(3, &users); err != sqldb.ErrInvalidCursor {
+			t.Errorf("tampered cursor should return ErrInvalidCursor, got `%v`", err)
+		}
+
+		if _, err := tests.DBEngine.Table("auth_user").Asc("id").After(page.NextCursor).CursorPaginate(3, &users); err != sqldb.ErrInvalidCursor {
+			t.Errorf("cursor for other order columns should return ErrInvalidCursor, got `%v`", err)
+		}
+	})
+}
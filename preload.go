@@ -0,0 +1,383 @@
+package sqldb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/binwen/sqldb/dialects"
+)
+
+type relationKind int
+
+const (
+	hasOne     relationKind = iota // 关联表的 foreignKey 指向当前模型的 references(默认 id)，至多一行
+	hasMany                        // 同 hasOne，可有多行
+	belongsTo                      // 当前模型的 foreignKey 指向关联表的 references(默认 id)
+	manyToMany                     // 通过 joinTable 关联，joinForeignKey 指向当前模型的 references(默认 id)，joinReferences 指向关联表的 foreignKey(默认 id)
+)
+
+var relationKinds = map[string]relationKind{
+	"hasOne":    hasOne,
+	"hasMany":   hasMany,
+	"belongsTo": belongsTo,
+	"many2many": manyToMany,
+}
+
+// 由 relation 标签声明的关联字段，格式为 "类型,table=关联表,foreignKey=字段,references=字段"，
+// many2many 另需 "joinTable=中间表,joinForeignKey=字段,joinReferences=字段"，
+// 关联字段需同时设置 db:"-"，如:
+//
+//	Profile *Profile    `db:"-" relation:"hasOne,table=user_profile,foreignKey=user_id"`
+//	Orders  []Order     `db:"-" relation:"hasMany,table=orders,foreignKey=user_id"`
+//	Company *Company    `db:"-" relation:"belongsTo,table=company,foreignKey=company_id"`
+//	Groups  []*AuthGroup `db:"-" relation:"many2many,table=auth_group,joinTable=auth_user_groups,joinForeignKey=user_id,joinReferences=group_id"`
+type relation struct {
+	kind           relationKind
+	field          reflect.StructField
+	elem           reflect.Type // 关联的 struct 类型
+	table          string
+	foreignKey     string
+	references     string
+	joinTable      string
+	joinForeignKey string
+	joinReferences string
+	err            error // 标签格式错误，在 Preload 时返回
+}
+
+func parseRelation(field reflect.StructField, tag string) *relation {
+	rel := &relation{field: field}
+	options := strings.Split(tag, ",")
+	kind, ok := relationKinds[strings.TrimSpace(options[0])]
+	if !ok {
+		rel.err = fmt.Errorf("unknown relation `%s` of field `%s`", options[0], field.Name)
+		return rel
+	}
+	rel.kind = kind
+
+	for _, option := range options[1:] {
+		kv := strings.SplitN(option, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch value := strings.TrimSpace(kv[1]); strings.TrimSpace(kv[0]) {
+		case "table":
+			rel.table = value
+		case "foreignKey":
+			rel.foreignKey = value
+		case "references":
+			rel.references = value
+		case "joinTable":
+			rel.joinTable = value
+		case "joinForeignKey":
+			rel.joinForeignKey = value
+		case "joinReferences":
+			rel.joinReferences = value
+		}
+	}
+
+	typ := field.Type
+	many := kind == hasMany || kind == manyToMany
+	if many {
+		if typ.Kind() != reflect.Slice {
+			rel.err = fmt.Errorf("relation field `%s` must be a slice", field.Name)
+			return rel
+		}
+		typ = typ.Elem()
+	}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	rel.elem = typ
+
+	switch {
+	case typ.Kind() != reflect.Struct:
+		rel.err = fmt.Errorf("relation field `%s` must be a struct, a struct pointer or a slice of them", field.Name)
+	case rel.table == "" || kind != manyToMany && rel.foreignKey == "":
+		rel.err = fmt.Errorf("relation field `%s` requires table and foreignKey", field.Name)
+	case kind == manyToMany && (rel.joinTable == "" || rel.joinForeignKey == "" || rel.joinReferences == ""):
+		rel.err = fmt.Errorf("relation field `%s` requires joinTable, joinForeignKey and joinReferences", field.Name)
+	}
+	if rel.references == "" {
+		rel.references = "id"
+	}
+	if rel.foreignKey == "" {
+		rel.foreignKey = "id"
+	}
+	return rel
+}
+
+type preload struct {
+	path       string
+	conditions []interface{}
+}
+
+// 预加载的关联字段树，children 按声明顺序加载
+type preloadNode struct {
+	conditions []interface{}
+	names      []string
+	children   map[string]*preloadNode
+}
+
+func (node *preloadNode) child(name string) *preloadNode {
+	if child, ok := node.children[name]; ok {
+		return child
+	}
+	if node.children == nil {
+		node.children = map[string]*preloadNode{}
+	}
+	child := &preloadNode{}
+	node.children[name] = child
+	node.names = append(node.names, name)
+	return child
+}
+
+// Find、First 查询后预加载关联字段，每个关联字段使用 IN 查询批量加载(many2many 另需查询中间表)，
+// 参数超过方言的占位符上限时分批查询。
+// path 为字段名，嵌套关联用 . 分隔；conditions 作用于 path 的最后一个字段，可以是查询条件或 ScopeFunc，如:
+//
+//	db.Table("auth_user").Preload("Groups", "name <> ?", "guest").Preload("Groups.Permissions").Find(&users)
+//	db.Table("auth_user").Preload("Orders", func(s *sqldb.Session) *sqldb.Session {
+//		return s.OrderBy("id DESC")
+//	}).First(&user)
+func (session *Session) Preload(path string, conditions ...interface{}) *Session {
+	session = session.getInstance()
+	session.preloads = append(session.preloads[:len(session.preloads):len(session.preloads)], preload{path: path, conditions: conditions})
+	return session
+}
+
+func (session *Session) loadPreloads(dest reflect.Value) error {
+	if len(session.preloads) == 0 || session.dryRun != nil {
+		return nil
+	}
+
+	root := &preloadNode{}
+	for _, p := range session.preloads {
+		node := root
+		for _, name := range strings.Split(p.path, ".") {
+			node = node.child(strings.TrimSpace(name))
+		}
+		if len(p.conditions) > 0 {
+			node.conditions = p.conditions
+		}
+	}
+	return session.preloadNode(collectStructs(dest, nil), root)
+}
+
+// 取出 struct、struct 指针或其 slice 中可寻址的 struct
+func collectStructs(value reflect.Value, structs []reflect.Value) []reflect.Value {
+	for value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return structs
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			structs = collectStructs(value.Index(i), structs)
+		}
+	case reflect.Struct:
+		if value.CanAddr() {
+			structs = append(structs, value)
+		}
+	}
+	return structs
+}
+
+func (session *Session) preloadNode(owners []reflect.Value, node *preloadNode) error {
+	if len(owners) == 0 || len(node.names) == 0 {
+		return nil
+	}
+
+	schema := parseSchema(owners[0].Type())
+	for _, name := range node.names {
+		rel, ok := schema.relations[name]
+		if !ok {
+			return fmt.Errorf("relation `%s` of `%s` is not declared", name, owners[0].Type())
+		}
+		if rel.err != nil {
+			return rel.err
+		}
+		if err := session.preloadRelation(owners, rel, node.children[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (session *Session) preloadRelation(owners []reflect.Value, rel *relation, node *preloadNode) error {
+	ownerColumn, relatedColumn := rel.references, rel.foreignKey
+	if rel.kind == belongsTo {
+		ownerColumn, relatedColumn = rel.foreignKey, rel.references
+	}
+
+	ownerKeys := make([]string, len(owners))
+	var args []interface{}
+	seen := map[string]bool{}
+	for i, owner := range owners {
+		value, err := columnValue(owner, ownerColumn)
+		if err != nil {
+			return err
+		}
+		if key, ok := relationKey(value); ok {
+			ownerKeys[i] = key
+			if !seen[key] {
+				seen[key] = true
+				args = append(args, value)
+			}
+		}
+	}
+
+	// many2many 先从中间表查询当前模型的 references 对应的关联表 foreignKey
+	joined := map[string][]string{}
+	if rel.kind == manyToMany && len(args) > 0 {
+		var pairs []map[string]interface{}
+		for _, chunk := range session.chunkArgs(args, 0) {
+			err := session.db.TableContext(session.ctx, rel.joinTable).Select(rel.joinForeignKey, rel.joinReferences).
+				Where(rel.joinForeignKey+" IN (?)", chunk).Find(&pairs)
+			if err != nil {
+				return err
+			}
+		}
+		args, seen = nil, map[string]bool{}
+		for _, pair := range pairs {
+			ownerKey, ok := relationKey(pair[rel.joinForeignKey])
+			relatedKey, ok2 := relationKey(pair[rel.joinReferences])
+			if !ok || !ok2 {
+				continue
+			}
+			joined[ownerKey] = append(joined[ownerKey], relatedKey)
+			if !seen[relatedKey] {
+				seen[relatedKey] = true
+				args = append(args, pair[rel.joinReferences])
+			}
+		}
+	}
+
+	related := reflect.New(reflect.SliceOf(reflect.PtrTo(rel.elem))).Elem()
+	if len(args) > 0 {
+		conditions := node.conditions
+		for _, chunk := range session.chunkArgs(args, len(conditions)) {
+			query := session.db.TableContext(session.ctx, rel.table).Where(relatedColumn+" IN (?)", chunk)
+			if len(conditions) > 0 {
+				if fn, ok := conditions[0].(func(*Session) *Session); ok {
+					query = query.Scopes(fn)
+				} else if fn, ok := conditions[0].(ScopeFunc); ok {
+					query = query.Scopes(fn)
+				} else {
+					query = query.Where(conditions[0], conditions[1:]...)
+				}
+			}
+			if err := query.Find(related.Addr().Interface()); err != nil {
+				return err
+			}
+		}
+		if err := session.preloadNode(collectStructs(related, nil), node); err != nil {
+			return err
+		}
+	}
+
+	relatedByKey := map[string][]reflect.Value{}
+	for i := 0; i < related.Len(); i++ {
+		value, err := columnValue(related.Index(i).Elem(), relatedColumn)
+		if err != nil {
+			return err
+		}
+		if key, ok := relationKey(value); ok {
+			relatedByKey[key] = append(relatedByKey[key], related.Index(i))
+		}
+	}
+
+	for i, owner := range owners {
+		var matched []reflect.Value
+		if rel.kind == manyToMany {
+			for _, key := range joined[ownerKeys[i]] {
+				matched = append(matched, relatedByKey[key]...)
+			}
+		} else if ownerKeys[i] != "" {
+			matched = relatedByKey[ownerKeys[i]]
+		}
+		assignRelated(owner.FieldByIndex(rel.field.Index), matched)
+	}
+	return nil
+}
+
+// 按方言的占位符上限拆分 IN 查询的参数，reserved 为查询条件另外占用的占位符数
+func (session *Session) chunkArgs(args []interface{}, reserved int) [][]interface{} {
+	size := len(args)
+	if limiter, ok := session.statement.Dialector.(dialects.PlaceholderLimiter); ok {
+		if size = limiter.MaxPlaceholders() - reserved; size < 1 {
+			size = 1
+		}
+	}
+
+	var chunks [][]interface{}
+	for start := 0; start < len(args); start += size {
+		end := start + size
+		if end > len(args) {
+			end = len(args)
+		}
+		chunks = append(chunks, args[start:end])
+	}
+	return chunks
+}
+
+// 将关联的行(struct 指针)赋值给 slice、struct 指针或 struct 字段
+func assignRelated(field reflect.Value, matched []reflect.Value) {
+	convert := func(typ reflect.Type, ptr reflect.Value) reflect.Value {
+		if typ.Kind() == reflect.Ptr {
+			return ptr
+		}
+		return ptr.Elem()
+	}
+
+	switch field.Kind() {
+	case reflect.Slice:
+		slice := reflect.MakeSlice(field.Type(), 0, len(matched))
+		for _, ptr := range matched {
+			slice = reflect.Append(slice, convert(field.Type().Elem(), ptr))
+		}
+		field.Set(slice)
+	default:
+		if len(matched) > 0 {
+			field.Set(convert(field.Type(), matched[0]))
+		} else {
+			field.Set(reflect.Zero(field.Type()))
+		}
+	}
+}
+
+func columnValue(value reflect.Value, column string) (interface{}, error) {
+	fi, ok := mapper.mapper.TypeMap(value.Type()).Names[column]
+	if !ok {
+		return nil, fmt.Errorf("column `%s` is not found in `%s`", column, value.Type())
+	}
+	return value.FieldByIndex(fi.Index).Interface(), nil
+}
+
+// 关联字段值的比较键，NULL 值返回 false
+func relationKey(value interface{}) (string, bool) {
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil {
+			return "", false
+		}
+		value = v
+	}
+	rv := reflect.ValueOf(value)
+	for rv.IsValid() && rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return "", false
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return "", false
+	}
+	if b, ok := rv.Interface().([]byte); ok {
+		return string(b), true
+	}
+	return fmt.Sprint(rv.Interface()), true
+}
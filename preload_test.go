@@ -0,0 +1,164 @@
+package sqldb_test
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/tests"
+)
+
+type preloadUser struct {
+	Id          int
+	UserName    string              `db:"username"`
+	GroupCount  int                 `db:"-"`
+	Groups      []*preloadGroup     `db:"-" relation:"many2many,table=auth_group,joinTable=auth_user_groups,joinForeignKey=user_id,joinReferences=group_id"`
+	Memberships []preloadMembership `db:"-" relation:"hasMany,table=auth_user_groups,foreignKey=user_id"`
+	Membership  *preloadMembership  `db:"-" relation:"hasOne,table=auth_user_groups,foreignKey=user_id"`
+}
+
+// 预加载完成后才调用 AfterFind
+func (user *preloadUser) AfterFind(ctx context.Context, db *sqldb.SqlDB) error {
+	user.GroupCount = len(user.Groups)
+	return nil
+}
+
+type preloadGroup struct {
+	Id      int
+	Name    string
+	Members []*preloadMembership `db:"-" relation:"hasMany,table=auth_user_groups,foreignKey=group_id"`
+}
+
+type preloadMembership struct {
+	Id      int
+	UserId  int           `db:"user_id"`
+	GroupId int           `db:"group_id"`
+	User    *preloadUser  `db:"-" relation:"belongsTo,table=auth_user,foreignKey=user_id"`
+	Group   preloadGroup  `db:"-" relation:"belongsTo,table=auth_group,foreignKey=group_id"`
+	Peers   []preloadUser `db:"-" relation:"many2many,table=auth_user,references=group_id,joinTable=auth_user_groups,joinForeignKey=group_id,joinReferences=user_id"`
+}
+
+func groupNames(groups []*preloadGroup) []string {
+	names := make([]string, 0, len(groups))
+	for _, group := range groups {
+		names = append(names, group.Name)
+	}
+	return names
+}
+
+func TestPreload(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3)
+		tests.InsertAuthGroup(1, 2, 3)
+		tests.InsertUserGroup(1, 1)
+		tests.InsertUserGroup(1, 2)
+		tests.InsertUserGroup(2, 2)
+
+		var users []preloadUser
+		if err := tests.DBEngine.Table("auth_user").Preload("Groups").OrderBy("id").Find(&users); err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != 3 || len(users[0].Groups) != 2 || len(users[1].Groups) != 1 || users[2].Groups == nil || len(users[2].Groups) != 0 {
+			t.Fatalf("many2many relation should be preloaded, got `%+v`", users)
+		}
+		if users[0].Groups[1] != users[1].Groups[0] || users[1].Groups[0].Name != "group2" {
+			t.Errorf("shared group should be loaded once, got `%v` `%v`", groupNames(users[0].Groups), groupNames(users[1].Groups))
+		}
+		if users[0].GroupCount != 2 || users[1].GroupCount != 1 {
+			t.Errorf("after find hook should see preloaded relations, got `%v` `%v`", users[0].GroupCount, users[1].GroupCount)
+		}
+
+		users = nil
+		if err := tests.DBEngine.Table("auth_user").Preload("Groups", "name <> ?", "group1").OrderBy("id").Find(&users); err != nil {
+			t.Fatal(err)
+		}
+		if names := groupNames(users[0].Groups); len(names) != 1 || names[0] != "group2" {
+			t.Errorf("preload conditions should be applied, got `%v`", names)
+		}
+
+		var user preloadUser
+		if err := tests.DBEngine.Table("auth_user").Preload("Memberships.Group").Preload("Membership").Where("id = ?", 1).First(&user); err != nil {
+			t.Fatal(err)
+		}
+		if len(user.Memberships) != 2 || user.Memberships[0].Group.Name != "group1" || user.Memberships[1].Group.Name != "group2" {
+			t.Errorf("nested relation should be preloaded, got `%+v`", user.Memberships)
+		}
+		if user.Membership == nil || user.Membership.UserId != 1 {
+			t.Errorf("has one relation should be preloaded, got `%+v`", user.Membership)
+		}
+
+		var memberships []*preloadMembership
+		if err := tests.DBEngine.Table("auth_user_groups").Preload("User").Where("group_id = ?", 2).OrderBy("user_id").Find(&memberships); err != nil {
+			t.Fatal(err)
+		}
+		if len(memberships) != 2 || memberships[0].User == nil || memberships[0].User.UserName != "user1" || memberships[1].User.UserName != "user2" {
+			t.Errorf("belongs to relation should be preloaded, got `%+v`", memberships)
+		}
+
+		memberships = nil
+		if err := tests.DBEngine.Table("auth_user_groups").Preload("Peers").Where("user_id = ?", 1).OrderBy("group_id").Find(&memberships); err != nil {
+			t.Fatal(err)
+		}
+		if len(memberships) != 2 || len(memberships[0].Peers) != 1 || len(memberships[1].Peers) != 2 {
+			t.Errorf("many2many relation should use the configured key columns, got `%+v`", memberships)
+		}
+
+		var groups []preloadGroup
+		err := tests.DBEngine.Table("auth_group").Preload("Members", func(s *sqldb.Session) *sqldb.Session {
+			return s.Where("user_id = ?", 2)
+		}).Preload("Members.User").OrderBy("id").Find(&groups)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(groups) != 3 || len(groups[0].Members) != 0 || len(groups[1].Members) != 1 || groups[1].Members[0].User.UserName != "user2" {
+			t.Errorf("scope conditions should be applied to preload, got `%+v`", groups)
+		}
+
+		if err := tests.DBEngine.Table("auth_user").Preload("Missing").Find(&users); err == nil {
+			t.Error("undeclared relation should return error")
+		}
+	})
+}
+
+func TestPreloadInChunks(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthGroup(1)
+		users := make([]tests.AuthUser, 1200)
+		members := make([]map[string]interface{}, len(users))
+		for i := range users {
+			users[i] = tests.AuthUser{Id: i + 1, UserName: "user" + strconv.Itoa(i), Age: 18, ModelTime: tests.ModelTime{DateJoined: time.Now()}}
+			members[i] = map[string]interface{}{"user_id": i + 1, "group_id": 1}
+		}
+		if _, err := tests.DBEngine.Table("auth_user").BulkCreate(users); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tests.DBEngine.Table("auth_user_groups").BulkCreate(members); err != nil {
+			t.Fatal(err)
+		}
+
+		var memberships []preloadMembership
+		if err := tests.DBEngine.Table("auth_user_groups").Preload("User").Find(&memberships); err != nil {
+			t.Fatal(err)
+		}
+		for _, membership := range memberships {
+			if membership.User == nil || membership.User.Id != membership.UserId {
+				t.Fatalf("belongs to relation should be preloaded in chunks, got `%+v`", membership)
+			}
+		}
+
+		var loaded []preloadUser
+		if err := tests.DBEngine.Table("auth_user").Preload("Groups").Find(&loaded); err != nil {
+			t.Fatal(err)
+		}
+		if len(loaded) != len(users) {
+			t.Fatalf("should find `%v` users, got `%v`", len(users), len(loaded))
+		}
+		for _, user := range loaded {
+			if len(user.Groups) != 1 {
+				t.Fatalf("many2many relation should be preloaded in chunks, got `%+v`", user)
+			}
+		}
+	})
+}
@@ -0,0 +1,271 @@
+package sqldb
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var queryNameRegexp = regexp.MustCompile(`^--\s*name:\s*(\S*)\s*$`)
+
+// SQL 文件中以 `-- name: QueryName` 标记的命名查询
+type NamedQuery struct {
+	Name   string
+	SQL    string
+	Source string   // 来源文件
+	Line   int      // 所在行号
+	Args   int      // 位置参数(? 或 $n)个数
+	Params []string // 命名参数(@name)
+}
+
+// 命名查询注册表，同一名称只能注册一次
+type QueryRegistry struct {
+	mu      sync.RWMutex
+	queries map[string]*NamedQuery
+}
+
+func NewQueryRegistry() *QueryRegistry {
+	return &QueryRegistry{queries: map[string]*NamedQuery{}}
+}
+
+func (r *QueryRegistry) Get(name string) (*NamedQuery, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	query, ok := r.queries[name]
+	return query, ok
+}
+
+// 注册命名查询，存在重复名称时全部不注册并返回所有重复项
+func (r *QueryRegistry) Add(queries ...*NamedQuery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var duplicates []string
+	pending := make(map[string]*NamedQuery, len(queries))
+	for _, query := range queries {
+		exist, ok := r.queries[query.Name]
+		if !ok {
+			exist, ok = pending[query.Name]
+		}
+		if ok {
+			duplicates = append(duplicates, fmt.Sprintf(
+				"`%s` (%s:%d, %s:%d)", query.Name, exist.Source, exist.Line, query.Source, query.Line,
+			))
+			continue
+		}
+		pending[query.Name] = query
+	}
+	if len(duplicates) > 0 {
+		return fmt.Errorf("duplicate named queries: %s", strings.Join(duplicates, ", "))
+	}
+
+	for name, query := range pending {
+		r.queries[name] = query
+	}
+	return nil
+}
+
+// 从 reader 中解析并注册命名查询，source 用于错误提示
+func (r *QueryRegistry) Load(source string, reader io.Reader) error {
+	queries, err := ParseQueries(source, reader)
+	if err != nil {
+		return err
+	}
+	return r.Add(queries...)
+}
+
+func (r *QueryRegistry) LoadFiles(paths ...string) error {
+	var queries []*NamedQuery
+	for _, path := range paths {
+		items, err := parseQueryFile(path, func() (io.ReadCloser, error) { return os.Open(path) })
+		if err != nil {
+			return err
+		}
+		queries = append(queries, items...)
+	}
+	return r.Add(queries...)
+}
+
+func parseQueryFile(path string, open func() (io.ReadCloser, error)) ([]*NamedQuery, error) {
+	file, err := open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseQueries(path, file)
+}
+
+// 解析 SQL 文本中的命名查询，每个查询以 `-- name: QueryName` 开始，直到下一个标记或文本结束；
+// 查询末尾的分号会被去掉，同一查询中不能混用 ?、$n 与 @name 占位符
+func ParseQueries(source string, reader io.Reader) (queries []*NamedQuery, err error) {
+	var (
+		current *NamedQuery
+		body    []string
+		lineNo  int
+	)
+
+	finish := func() error {
+		if current == nil {
+			return nil
+		}
+		current.SQL = strings.TrimSuffix(strings.TrimSpace(strings.Join(body, "\n")), ";")
+		if strings.TrimSpace(current.SQL) == "" {
+			return fmt.Errorf("%s:%d: named query `%s` is empty", current.Source, current.Line, current.Name)
+		}
+		if current.Args, current.Params, err = parsePlaceholders(current.SQL); err != nil {
+			return fmt.Errorf("%s:%d: named query `%s`: %v", current.Source, current.Line, current.Name, err)
+		}
+		queries = append(queries, current)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if matches := queryNameRegexp.FindStringSubmatch(trimmed); matches != nil {
+			if err := finish(); err != nil {
+				return nil, err
+			}
+			if matches[1] == "" {
+				return nil, fmt.Errorf("%s:%d: missing query name", source, lineNo)
+			}
+			current = &NamedQuery{Name: matches[1], Source: source, Line: lineNo}
+			body = body[:0]
+			continue
+		}
+
+		if current == nil {
+			if trimmed != "" && !strings.HasPrefix(trimmed, "--") {
+				return nil, fmt.Errorf("%s:%d: sql outside of named query", source, lineNo)
+			}
+			continue
+		}
+		body = append(body, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if err := finish(); err != nil {
+		return nil, err
+	}
+	return queries, nil
+}
+
+// 统计占位符，跳过字符串与注释；返回位置参数个数与命名参数
+func parsePlaceholders(query string) (args int, params []string, err error) {
+	var (
+		positional int
+		numbered   = map[int]bool{}
+		named      = map[string]bool{}
+	)
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			for i++; i < len(query) && query[i] != c; i++ {
+			}
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			for ; i < len(query) && query[i] != '\n'; i++ {
+			}
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			if end := strings.Index(query[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(query)
+			}
+		case c == '?':
+			positional++
+		case c == '$' && i+1 < len(query) && query[i+1] >= '0' && query[i+1] <= '9':
+			j := i + 1
+			for j < len(query) && query[j] >= '0' && query[j] <= '9' {
+				j++
+			}
+			n, _ := strconv.Atoi(query[i+1 : j])
+			numbered[n] = true
+			i = j - 1
+		case c == '@' && i+1 < len(query) && query[i+1] == '@':
+			j := i + 2
+			for j < len(query) && isIdentChar(query[j]) {
+				j++
+			}
+			i = j - 1
+		case c == '@' && i+1 < len(query) && isIdentChar(query[i+1]):
+			j := i + 1
+			for j < len(query) && isIdentChar(query[j]) {
+				j++
+			}
+			name := query[i+1 : j]
+			if !named[name] {
+				named[name] = true
+				params = append(params, name)
+			}
+			i = j - 1
+		}
+	}
+
+	kinds := 0
+	for _, used := range []bool{positional > 0, len(numbered) > 0, len(named) > 0} {
+		if used {
+			kinds++
+		}
+	}
+	if kinds > 1 {
+		return 0, nil, fmt.Errorf("mixed ?, $n and @name placeholders")
+	}
+
+	for n := 1; n <= len(numbered); n++ {
+		if !numbered[n] {
+			return 0, nil, fmt.Errorf("placeholder $%d is missing", n)
+		}
+	}
+	return positional + len(numbered), params, nil
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// 校验调用参数与查询占位符是否一致
+func (query *NamedQuery) checkArgs(args []interface{}) error {
+	if len(query.Params) > 0 {
+		namedVars, ok := ConvertNamedVars(args)
+		if !ok {
+			return fmt.Errorf("named query `%s` requires named arguments %v", query.Name, query.Params)
+		}
+
+		provided := make(map[string]bool, len(namedVars))
+		for _, v := range namedVars {
+			provided[v.(sql.NamedArg).Name] = true
+		}
+		for _, name := range query.Params {
+			if !provided[name] {
+				return fmt.Errorf("named query `%s` missing argument `%s`", query.Name, name)
+			}
+		}
+		return nil
+	}
+
+	if len(args) != query.Args {
+		return fmt.Errorf("named query `%s` expects %d arguments, got %d", query.Name, query.Args, len(args))
+	}
+	return nil
+}
+
+func (db *SqlDB) named(ctx context.Context, registry *QueryRegistry, name string, args []interface{}) *RawSession {
+	query, ok := registry.Get(name)
+	if !ok {
+		return &RawSession{ctx: ctx, db: db, err: fmt.Errorf("named query `%s` is not registered", name)}
+	}
+	return &RawSession{ctx: ctx, db: db, query: query.SQL, vars: args, err: query.checkArgs(args)}
+}
@@ -0,0 +1,43 @@
+//go:build go1.16
+// +build go1.16
+
+package sqldb
+
+import (
+	"io"
+	"io/fs"
+	"sort"
+)
+
+// 从文件系统(如 embed.FS)中加载匹配 patterns 的 SQL 文件，未指定 patterns 时加载根目录下所有 .sql 文件
+func (r *QueryRegistry) LoadFS(fsys fs.FS, patterns ...string) error {
+	if len(patterns) == 0 {
+		patterns = []string{"*.sql"}
+	}
+
+	var paths []string
+	for _, pattern := range patterns {
+		matches, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+
+	var queries []*NamedQuery
+	for _, path := range paths {
+		path := path
+		items, err := parseQueryFile(path, func() (io.ReadCloser, error) { return fsys.Open(path) })
+		if err != nil {
+			return err
+		}
+		queries = append(queries, items...)
+	}
+	return r.Add(queries...)
+}
+
+// 从文件系统(如 embed.FS)中加载命名查询
+func (eg *EngineGroup) LoadSQLFS(fsys fs.FS, patterns ...string) error {
+	return eg.queries.LoadFS(fsys, patterns...)
+}
@@ -0,0 +1,40 @@
+//go:build go1.16
+// +build go1.16
+
+package sqldb_test
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/binwen/sqldb"
+)
+
+func TestQueryRegistryDuplicates(t *testing.T) {
+	registry := sqldb.NewQueryRegistry()
+	fsys := fstest.MapFS{
+		"a.sql": {Data: []byte("-- name: Q1\nselect 1\n-- name: Q2\nselect 2")},
+		"b.sql": {Data: []byte("-- name: Q2\nselect 2\n-- name: Q1\nselect 1")},
+	}
+
+	err := registry.LoadFS(fsys)
+	if err == nil {
+		t.Fatal("load duplicate queries should fail")
+	}
+	for _, expected := range []string{"`Q1` (a.sql:1, b.sql:3)", "`Q2` (a.sql:3, b.sql:1)"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("error should report %s, got `%v`", expected, err)
+		}
+	}
+	if _, ok := registry.Get("Q1"); ok {
+		t.Error("no query should be registered when loading fails")
+	}
+
+	if err := registry.LoadFS(fsys, "a.sql"); err != nil {
+		t.Fatal(err)
+	}
+	if err := registry.Load("c.sql", strings.NewReader("-- name: Q1\nselect 3")); err == nil {
+		t.Error("query registered before should be reported as duplicate")
+	}
+}
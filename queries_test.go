@@ -0,0 +1,112 @@
+package sqldb_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/tests"
+)
+
+const userQueries = `
+-- 用户相关查询
+
+-- name: GetUserByName
+select * from auth_user where username = ?;
+
+-- name: ListUsersByAge
+-- 按年龄查询
+select id from auth_user
+where age = @age and id <> @id
+order by id;
+
+-- name: UpdateUserAge
+update auth_user set age = ? where id in (?)
+`
+
+func TestParseQueries(t *testing.T) {
+	queries, err := sqldb.ParseQueries("user.sql", strings.NewReader(userQueries))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 3 {
+		t.Fatalf("should parse `3` queries, got `%v`", len(queries))
+	}
+
+	if queries[0].Name != "GetUserByName" || queries[0].SQL != "select * from auth_user where username = ?" || queries[0].Args != 1 {
+		t.Errorf("unexpected query: %+v", queries[0])
+	}
+	if queries[1].Line != 7 || queries[1].Args != 0 || strings.Join(queries[1].Params, ",") != "age,id" {
+		t.Errorf("unexpected query: %+v", queries[1])
+	}
+	if queries[2].Args != 2 {
+		t.Errorf("query `UpdateUserAge` should have `2` arguments, got `%v`", queries[2].Args)
+	}
+
+	invalids := map[string]string{
+		"select 1": "sql outside of named query",
+		"-- name: Empty\n\n-- name: Other\nselect 1":     "named query `Empty` is empty",
+		"-- name: Mixed\nselect ? from t where id = @id": "mixed ?, $n and @name placeholders",
+		"-- name: Gap\nselect $1 from t where id = $3":   "placeholder $2 is missing",
+		"-- name: \nselect 1":                            "missing query name",
+	}
+	for text, message := range invalids {
+		if _, err := sqldb.ParseQueries("invalid.sql", strings.NewReader(text)); err == nil || !strings.Contains(err.Error(), message) {
+			t.Errorf("parse %q should fail with `%s`, got `%v`", text, message, err)
+		}
+	}
+
+	queries, err = sqldb.ParseQueries("quoted.sql", strings.NewReader(
+		"-- name: Quoted\nselect '?', @@version /* @id */ from t where a = ? -- and b = ?",
+	))
+	if err != nil {
+		t.Fatal(err)
+	} else if queries[0].Args != 1 || len(queries[0].Params) != 0 {
+		t.Errorf("placeholders in quotes, comments and system variables should be ignored, got %+v", queries[0])
+	}
+}
+
+func TestNamed(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3)
+		if _, ok := tests.DBEngine.Queries().Get("GetUserByName"); !ok {
+			if err := tests.DBEngine.Queries().Load("user.sql", strings.NewReader(userQueries)); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var user tests.AuthUser
+		if err := tests.DBEngine.Named("GetUserByName", "user2").Fetch(&user); err != nil {
+			t.Error(err)
+		} else if user.Id != 2 {
+			t.Errorf("user id should be `2`, got `%v`", user.Id)
+		}
+
+		if result, err := tests.DBEngine.Named("UpdateUserAge", 30, []int{1, 2}).Exec(); err != nil {
+			t.Error(err)
+		} else if affected, _ := result.RowsAffected(); affected != 2 {
+			t.Errorf("rows affected should be `2`, got `%v`", affected)
+		}
+
+		var ids []int
+		if err := tests.DBEngine.Named("ListUsersByAge", map[string]interface{}{"age": 30, "id": 1}).Fetch(&ids); err != nil {
+			t.Error(err)
+		} else if len(ids) != 1 || ids[0] != 2 {
+			t.Errorf("ids should be `[2]`, got `%v`", ids)
+		}
+
+		if err := tests.DBEngine.Named("NotExists").Fetch(&ids); err == nil || !strings.Contains(err.Error(), "not registered") {
+			t.Errorf("unknown named query should return error, got `%v`", err)
+		}
+		var id int
+		if err := tests.DBEngine.Named("NotExists").QueryRow().Scan(&id); err == nil || !strings.Contains(err.Error(), "not registered") {
+			t.Errorf("unknown named query row should return error, got `%v`", err)
+		}
+		if _, err := tests.DBEngine.Named("UpdateUserAge", 30).Exec(); err == nil || !strings.Contains(err.Error(), "expects 2 arguments, got 1") {
+			t.Errorf("argument count mismatch should return error, got `%v`", err)
+		}
+		if err := tests.DBEngine.Named("ListUsersByAge", map[string]interface{}{"age": 30}).Fetch(&ids); err == nil || !strings.Contains(err.Error(), "missing argument `id`") {
+			t.Errorf("missing named argument should return error, got `%v`", err)
+		}
+	})
+}
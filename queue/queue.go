@@ -0,0 +1,256 @@
+// 基于数据库表的任务队列，支持延迟执行、可见性超时、失败重试与死信
+package queue
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/clause"
+	"github.com/binwen/sqldb/dialects"
+	"github.com/binwen/sqldb/internal/jobs"
+)
+
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDead    = "dead"
+)
+
+// 任务已超过可见性超时并被其他 worker 重新领取，当前 worker 无法再确认或失败该任务
+var ErrJobLost = errors.New("job is no longer owned by this worker")
+
+// 队列中的任务，时间均为毫秒时间戳
+type Job struct {
+	ID          int64  `db:"id"`
+	Queue       string `db:"queue"`
+	Payload     []byte `db:"payload"`
+	Status      string `db:"status"`
+	Attempts    int    `db:"attempts"`
+	MaxAttempts int    `db:"max_attempts"`
+	RunAt       int64  `db:"run_at"` // 待执行任务为计划执行时间，执行中任务为可见性超时时间
+	Token       string `db:"token"`  // 领取任务时生成的标识
+	LastError   string `db:"last_error"`
+	CreatedAt   int64  `db:"created_at"`
+}
+
+type Options struct {
+	Table             string                           // 任务表名，默认为 sqldb_jobs
+	VisibilityTimeout time.Duration                    // 领取后未确认的任务在超时后可被重新领取，默认 30 秒
+	MaxAttempts       int                              // 默认最大执行次数，默认 5 次
+	Backoff           func(attempts int) time.Duration // 失败后的重试间隔，默认从 1 秒开始指数增长，最长 1 小时
+	Clock             func() time.Time                 // 时钟，默认使用数据库的时钟(SetNowFunc)，可在测试中替换
+}
+
+type EnqueueOptions struct {
+	RunAt       time.Time     // 计划执行时间
+	Delay       time.Duration // 延迟执行，RunAt 为空时有效
+	MaxAttempts int           // 最大执行次数，默认使用 Options.MaxAttempts
+}
+
+type Queue struct {
+	db           *sqldb.EngineGroup
+	opts         Options
+	noSkipLocked int32 // 数据库(或当前版本)不支持 SKIP LOCKED 时置为 1，之后使用原子 UPDATE 领取任务
+}
+
+func New(db *sqldb.EngineGroup, opts ...Options) *Queue {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Table == "" {
+		opt.Table = "sqldb_jobs"
+	}
+	if opt.VisibilityTimeout <= 0 {
+		opt.VisibilityTimeout = 30 * time.Second
+	}
+	if opt.MaxAttempts <= 0 {
+		opt.MaxAttempts = 5
+	}
+	if opt.Backoff == nil {
+		opt.Backoff = DefaultBackoff
+	}
+	opt.Clock = jobs.Clock(db, opt.Clock)
+	return &Queue{db: db, opts: opt}
+}
+
+// 默认重试间隔: 1s、2s、4s ... 最长 1 小时
+func DefaultBackoff(attempts int) time.Duration {
+	return jobs.Backoff(attempts, time.Hour)
+}
+
+func (q *Queue) now() int64 {
+	return jobs.Millis(q.opts.Clock())
+}
+
+// 创建任务表及索引
+func (q *Queue) Migrate(ctx context.Context) error {
+	return jobs.Migrate(ctx, q.db, q.opts.Table, `  id %s,
+  queue VARCHAR(100) NOT NULL,
+  payload %s,
+  status VARCHAR(16) NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  max_attempts INT NOT NULL,
+  run_at BIGINT NOT NULL,
+  token VARCHAR(64) NOT NULL DEFAULT '',
+  last_error TEXT NOT NULL,
+  created_at BIGINT NOT NULL`, map[string]string{"fetch": "queue, status, run_at"})
+}
+
+// 添加任务，返回任务 ID
+func (q *Queue) Enqueue(ctx context.Context, name string, payload []byte, opts ...EnqueueOptions) (int64, error) {
+	return q.EnqueueTx(ctx, q.db.Use(), name, payload, opts...)
+}
+
+// 在指定的连接或事务中添加任务，任务随事务一起提交
+func (q *Queue) EnqueueTx(ctx context.Context, db *sqldb.SqlDB, name string, payload []byte, opts ...EnqueueOptions) (int64, error) {
+	var opt EnqueueOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	now := q.now()
+	runAt := now + int64(opt.Delay/time.Millisecond)
+	if !opt.RunAt.IsZero() {
+		runAt = jobs.Millis(opt.RunAt)
+	}
+	if opt.MaxAttempts <= 0 {
+		opt.MaxAttempts = q.opts.MaxAttempts
+	}
+
+	return db.TableContext(ctx, q.opts.Table).Create(map[string]interface{}{
+		"queue":        name,
+		"payload":      payload,
+		"status":       StatusPending,
+		"attempts":     0,
+		"max_attempts": opt.MaxAttempts,
+		"run_at":       runAt,
+		"token":        "",
+		"last_error":   "",
+		"created_at":   now,
+	})
+}
+
+// 领取最多 limit 个可执行的任务，任务在可见性超时前不会被其他 worker 领取；
+// 超时未确认且已达到最大执行次数的任务会被移入死信
+func (q *Queue) Dequeue(ctx context.Context, name string, limit int) (jobs []*Job, err error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	now := q.now()
+	if _, err := q.db.TableContext(ctx, q.opts.Table).Where(
+		"queue = ? AND status = ? AND run_at <= ? AND attempts >= max_attempts", name, StatusRunning, now,
+	).BulkUpdate(map[string]interface{}{
+		"status": StatusDead, "token": "", "last_error": "visibility timeout exceeded",
+	}); err != nil {
+		return nil, err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	claim := map[string]interface{}{
+		"status":   StatusRunning,
+		"run_at":   now + int64(q.opts.VisibilityTimeout/time.Millisecond),
+		"token":    token,
+		"attempts": clause.Expr{SQL: "attempts + 1"},
+	}
+	available := []interface{}{
+		"queue = ? AND status IN (?) AND run_at <= ? AND attempts < max_attempts",
+		name, []string{StatusPending, StatusRunning}, now,
+	}
+
+	// 优先使用 FOR UPDATE SKIP LOCKED，方言按服务端版本判断不支持时回退到原子 UPDATE
+	if atomic.LoadInt32(&q.noSkipLocked) == 0 {
+		err = q.db.TxContext(ctx, func(tx *sqldb.SqlDB) error {
+			var ids []int64
+			if err := tx.TableContext(ctx, q.opts.Table).Where(available[0], available[1:]...).Asc("run_at", "id").
+				Limit(limit).ForUpdate().SkipLocked().Pluck("id", &ids); err != nil || len(ids) == 0 {
+				return err
+			}
+			_, err := tx.TableContext(ctx, q.opts.Table).Where("id IN (?)", ids).BulkUpdate(claim)
+			return err
+		})
+		if errors.Is(err, dialects.ErrUnsupportedLock) {
+			atomic.StoreInt32(&q.noSkipLocked, 1)
+		}
+	}
+	if atomic.LoadInt32(&q.noSkipLocked) == 1 {
+		var ids []int64
+		if err = q.db.TableContext(ctx, q.opts.Table).Master().Where(available[0], available[1:]...).Asc("run_at", "id").
+			Limit(limit).Pluck("id", &ids); err == nil && len(ids) > 0 {
+			// 再次校验领取条件，写操作串行执行，并发领取时只有一个 worker 能更新成功
+			_, err = q.db.TableContext(ctx, q.opts.Table).Where("id IN (?)", ids).
+				Where(available[0], available[1:]...).BulkUpdate(claim)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 从主库读取刚领取的任务，从库可能尚未同步
+	err = q.db.TableContext(ctx, q.opts.Table).Master().Where("token = ?", token).Asc("run_at", "id").Find(&jobs)
+	return jobs, err
+}
+
+// 任务执行成功，删除任务
+func (q *Queue) Complete(ctx context.Context, job *Job) error {
+	affected, err := q.db.TableContext(ctx, q.opts.Table).Where("id = ? AND token = ?", job.ID, job.Token).Delete()
+	if err == nil && affected == 0 {
+		return ErrJobLost
+	}
+	return err
+}
+
+// 任务执行失败，未达到最大执行次数时按退避时间重新排队，否则移入死信
+func (q *Queue) Fail(ctx context.Context, job *Job, cause error) error {
+	data := map[string]interface{}{"token": "", "last_error": ""}
+	if cause != nil {
+		data["last_error"] = cause.Error()
+	}
+	if job.Attempts >= job.MaxAttempts {
+		data["status"] = StatusDead
+	} else {
+		data["status"] = StatusPending
+		data["run_at"] = q.now() + int64(q.opts.Backoff(job.Attempts)/time.Millisecond)
+	}
+
+	affected, err := q.db.TableContext(ctx, q.opts.Table).Where("id = ? AND token = ?", job.ID, job.Token).BulkUpdate(data)
+	if err == nil && affected == 0 {
+		return ErrJobLost
+	}
+	return err
+}
+
+// 查询死信任务
+func (q *Queue) DeadJobs(ctx context.Context, name string) (jobs []*Job, err error) {
+	err = q.db.TableContext(ctx, q.opts.Table).Where("queue = ? AND status = ?", name, StatusDead).Asc("id").Find(&jobs)
+	return
+}
+
+// 将死信任务重新排队，重置执行次数
+func (q *Queue) Retry(ctx context.Context, id int64) error {
+	affected, err := q.db.TableContext(ctx, q.opts.Table).Where("id = ? AND status = ?", id, StatusDead).BulkUpdate(map[string]interface{}{
+		"status": StatusPending, "attempts": 0, "run_at": q.now(),
+	})
+	if err == nil && affected == 0 {
+		return fmt.Errorf("dead job `%d` not found", id)
+	}
+	return err
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,229 @@
+package queue_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/binwen/sqldb/queue"
+	"github.com/binwen/sqldb/tests"
+)
+
+func newQueue(t *testing.T, opts queue.Options) *queue.Queue {
+	opts.Table = "sqldb_test_jobs"
+	q := queue.New(tests.DBEngine, opts)
+	tests.MigrateTable(t, opts.Table, q.Migrate)
+	return q
+}
+
+func TestEnqueueDequeue(t *testing.T) {
+	ctx := context.Background()
+	c := tests.NewClock(time.Unix(1600000000, 0))
+	q := newQueue(t, queue.Options{Clock: c.Now, VisibilityTimeout: time.Minute})
+
+	first, err := q.Enqueue(ctx, "email", []byte("first"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Enqueue(ctx, "email", []byte("later"), queue.EnqueueOptions{Delay: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.Enqueue(ctx, "sms", []byte("other")); err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := q.Dequeue(ctx, "email", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 1 || jobs[0].ID != first || string(jobs[0].Payload) != "first" {
+		t.Fatalf("should only dequeue the due job, got `%+v`", jobs)
+	}
+	if jobs[0].Attempts != 1 || jobs[0].Status != queue.StatusRunning || jobs[0].Token == "" {
+		t.Errorf("dequeued job should be running, got `%+v`", jobs[0])
+	}
+
+	if again, err := q.Dequeue(ctx, "email", 10); err != nil || len(again) != 0 {
+		t.Errorf("running job should be invisible, got `%v` `%v`", again, err)
+	}
+
+	c.Add(2 * time.Minute)
+	redelivered, err := q.Dequeue(ctx, "email", 10)
+	if err != nil || len(redelivered) != 1 || redelivered[0].ID != first || redelivered[0].Attempts != 2 {
+		t.Fatalf("job should be redelivered after visibility timeout, got `%+v` `%v`", redelivered, err)
+	}
+	if err := q.Complete(ctx, jobs[0]); err != queue.ErrJobLost {
+		t.Errorf("stale worker should lose the job, got `%v`", err)
+	}
+	if err := q.Complete(ctx, redelivered[0]); err != nil {
+		t.Error(err)
+	}
+
+	c.Add(time.Hour)
+	if scheduled, err := q.Dequeue(ctx, "email", 10); err != nil || len(scheduled) != 1 || string(scheduled[0].Payload) != "later" {
+		t.Errorf("scheduled job should run after delay, got `%+v` `%v`", scheduled, err)
+	}
+}
+
+func TestFailRetryAndDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	c := tests.NewClock(time.Unix(1600000000, 0))
+	q := newQueue(t, queue.Options{Clock: c.Now, Backoff: func(attempts int) time.Duration {
+		return time.Duration(attempts) * time.Minute
+	}})
+
+	id, err := q.Enqueue(ctx, "report", []byte("data"), queue.EnqueueOptions{MaxAttempts: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jobs, err := q.Dequeue(ctx, "report", 1)
+	if err != nil || len(jobs) != 1 {
+		t.Fatalf("dequeue failed, got `%v` `%v`", jobs, err)
+	}
+	if err := q.Fail(ctx, jobs[0], errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+	if jobs, _ := q.Dequeue(ctx, "report", 1); len(jobs) != 0 {
+		t.Errorf("failed job should wait for backoff, got `%+v`", jobs)
+	}
+
+	c.Add(time.Minute)
+	if jobs, err = q.Dequeue(ctx, "report", 1); err != nil || len(jobs) != 1 || jobs[0].LastError != "boom" {
+		t.Fatalf("failed job should be retried after backoff, got `%+v` `%v`", jobs, err)
+	}
+	if err := q.Fail(ctx, jobs[0], errors.New("boom again")); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Add(time.Hour)
+	if jobs, _ := q.Dequeue(ctx, "report", 1); len(jobs) != 0 {
+		t.Errorf("exhausted job should not be retried, got `%+v`", jobs)
+	}
+	dead, err := q.DeadJobs(ctx, "report")
+	if err != nil || len(dead) != 1 || dead[0].ID != id || dead[0].LastError != "boom again" {
+		t.Fatalf("exhausted job should be dead-lettered, got `%+v` `%v`", dead, err)
+	}
+
+	if err := q.Retry(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if jobs, err := q.Dequeue(ctx, "report", 1); err != nil || len(jobs) != 1 || jobs[0].Attempts != 1 {
+		t.Errorf("retried dead job should be available again, got `%+v` `%v`", jobs, err)
+	}
+}
+
+func TestVisibilityTimeoutDeadLetter(t *testing.T) {
+	ctx := context.Background()
+	c := tests.NewClock(time.Unix(1600000000, 0))
+	q := newQueue(t, queue.Options{Clock: c.Now, VisibilityTimeout: time.Minute, MaxAttempts: 1})
+
+	if _, err := q.Enqueue(ctx, "import", nil); err != nil {
+		t.Fatal(err)
+	}
+	if jobs, err := q.Dequeue(ctx, "import", 1); err != nil || len(jobs) != 1 {
+		t.Fatalf("dequeue failed, got `%v` `%v`", jobs, err)
+	}
+
+	c.Add(2 * time.Minute)
+	if jobs, _ := q.Dequeue(ctx, "import", 1); len(jobs) != 0 {
+		t.Errorf("timed out job without attempts left should not be redelivered, got `%+v`", jobs)
+	}
+	if dead, err := q.DeadJobs(ctx, "import"); err != nil || len(dead) != 1 {
+		t.Errorf("timed out job should be dead-lettered, got `%+v` `%v`", dead, err)
+	}
+}
+
+func TestWork(t *testing.T) {
+	q := newQueue(t, queue.Options{Backoff: func(int) time.Duration { return 0 }})
+
+	const total = 20
+	for i := 0; i < total; i++ {
+		if _, err := q.Enqueue(context.Background(), "work", []byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		mu        sync.Mutex
+		processed = map[byte]int{}
+		panicked  int32
+		running   int32
+		maxActive int32
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- q.Work(ctx, "work", func(ctx context.Context, job *queue.Job) error {
+			active := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				max := atomic.LoadInt32(&maxActive)
+				if active <= max || atomic.CompareAndSwapInt32(&maxActive, max, active) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			if job.Payload[0] == 3 && atomic.CompareAndSwapInt32(&panicked, 0, 1) {
+				panic("first attempt panics")
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			processed[job.Payload[0]]++
+			if len(processed) == total {
+				cancel()
+			}
+			return nil
+		}, queue.WorkerOptions{Concurrency: 4, PollInterval: 10 * time.Millisecond, OnError: func(err error) {
+			t.Errorf("worker should not report error, got `%v`", err)
+		}})
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		cancel()
+		t.Fatal("worker did not finish in time")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for i := 0; i < total; i++ {
+		if processed[byte(i)] != 1 {
+			t.Errorf("job %d should be processed once, got %d", i, processed[byte(i)])
+		}
+	}
+	if maxActive > 4 {
+		t.Errorf("concurrency should not exceed 4, got %d", maxActive)
+	}
+	if jobs, err := q.Dequeue(context.Background(), "work", total); err != nil || len(jobs) != 0 {
+		t.Errorf("all jobs should be completed, got `%+v` `%v`", jobs, err)
+	}
+}
+
+func TestEngineClock(t *testing.T) {
+	ctx := context.Background()
+	c := tests.NewClock(time.Unix(1600000000, 0))
+	tests.DBEngine.SetNowFunc(c.Now)
+	defer tests.DBEngine.SetNowFunc(nil)
+	q := newQueue(t, queue.Options{})
+
+	if _, err := q.Enqueue(ctx, "engine", nil, queue.EnqueueOptions{Delay: time.Minute}); err != nil {
+		t.Fatal(err)
+	}
+	if jobs, err := q.Dequeue(ctx, "engine", 1); err != nil || len(jobs) != 0 {
+		t.Fatalf("delayed job should not be due on the engine clock, got `%+v` `%v`", jobs, err)
+	}
+	c.Add(2 * time.Minute)
+	if jobs, err := q.Dequeue(ctx, "engine", 1); err != nil || len(jobs) != 1 || jobs[0].CreatedAt != 1600000000000 {
+		t.Errorf("queue should default to the engine clock, got `%+v` `%v`", jobs, err)
+	}
+}
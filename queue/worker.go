@@ -0,0 +1,108 @@
+package queue
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 任务处理函数，返回错误时任务按退避时间重试
+type Handler func(ctx context.Context, job *Job) error
+
+type WorkerOptions struct {
+	Concurrency  int           // 并发执行的任务数，默认 1
+	PollInterval time.Duration // 队列为空时的轮询间隔，默认 1 秒
+	OnError      func(error)   // 领取、确认任务出错时的回调
+}
+
+// 启动 worker 池处理队列中的任务，阻塞直到 ctx 取消；
+// ctx 取消后停止领取新任务，等待执行中的任务完成后返回
+func (q *Queue) Work(ctx context.Context, name string, handler Handler, opts ...WorkerOptions) error {
+	var opt WorkerOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Concurrency <= 0 {
+		opt.Concurrency = 1
+	}
+	if opt.PollInterval <= 0 {
+		opt.PollInterval = time.Second
+	}
+	onError := func(err error) {
+		if err != nil && opt.OnError != nil {
+			opt.OnError(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	idle := make(chan struct{}, opt.Concurrency)
+	for i := 0; i < opt.Concurrency; i++ {
+		idle <- struct{}{}
+	}
+	defer wg.Wait()
+
+	for {
+		// 等待空闲的 worker
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-idle:
+		}
+		free := 1
+	collect:
+		for free < opt.Concurrency {
+			select {
+			case <-idle:
+				free++
+			default:
+				break collect
+			}
+		}
+
+		// 使用独立的 context 领取任务，避免取消时任务已领取但未返回
+		jobs, err := q.Dequeue(context.Background(), name, free)
+		onError(err)
+		for _, job := range jobs {
+			wg.Add(1)
+			go func(job *Job) {
+				defer func() {
+					wg.Done()
+					idle <- struct{}{}
+				}()
+				onError(q.process(job, handler))
+			}(job)
+		}
+		for i := len(jobs); i < free; i++ {
+			idle <- struct{}{}
+		}
+
+		if len(jobs) < free {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(opt.PollInterval):
+			}
+		}
+	}
+}
+
+// 执行任务，处理函数的执行时间受可见性超时限制，panic 视为执行失败
+func (q *Queue) process(job *Job, handler Handler) error {
+	ctx, cancel := context.WithTimeout(context.Background(), q.opts.VisibilityTimeout)
+	defer cancel()
+
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("job panic: %v", r)
+			}
+		}()
+		return handler(ctx, job)
+	}()
+
+	if err != nil {
+		return q.Fail(context.Background(), job, err)
+	}
+	return q.Complete(context.Background(), job)
+}
@@ -14,9 +14,14 @@ type RawSession struct {
 	vars  []interface{}
 	db    *SqlDB
 	ctx   context.Context
+	err   error
 }
 
 func (raw *RawSession) Fetch(dest interface{}) error {
+	if raw.err != nil {
+		return raw.err
+	}
+
 	destRefValue := reflect.ValueOf(dest)
 	if IsNil(destRefValue) {
 		return errors.New("nil pointer passed to scan destination")
@@ -27,24 +32,38 @@ func (raw *RawSession) Fetch(dest interface{}) error {
 		return err
 	}
 	defer rows.Close()
-	destWrapper := DestWrapper{Dest: dest, ReflectValue: reflect.Indirect(destRefValue)}
+	destWrapper := DestWrapper{Dest: dest, ReflectValue: reflect.Indirect(destRefValue), ctx: raw.ctx, db: raw.db}
 
 	return ScanAll(rows, destWrapper)
 }
 
 func (raw *RawSession) Exec() (result sql.Result, err error) {
+	if raw.err != nil {
+		return nil, raw.err
+	}
 	return raw.db.ExecContext(raw.ctx, raw.query, raw.vars...)
 }
 
 func (raw *RawSession) Query() (rows *sqlx.Rows, err error) {
+	if raw.err != nil {
+		return nil, raw.err
+	}
 	return raw.db.QueryContext(raw.ctx, raw.query, raw.vars...)
 }
 
+// 命名查询不存在、参数校验或模板渲染失败时，返回的行 Scan 时返回该错误
 func (raw *RawSession) QueryRow() (row *sqlx.Row) {
+	if raw.err != nil {
+		return errRow(raw.err)
+	}
 	return raw.db.QueryRowContext(raw.ctx, raw.query, raw.vars...)
 }
 
 func (raw *RawSession) Master() *RawSession {
-	raw.db.isMaster = true
+	raw.db = raw.db.master()
 	return raw
 }
+
+func (raw *RawSession) Err() error {
+	return raw.err
+}
@@ -0,0 +1,133 @@
+package sqldb
+
+import (
+	"errors"
+	"reflect"
+
+	"github.com/binwen/sqldb/clause"
+	"github.com/binwen/sqldb/dialects"
+)
+
+// 设置 Update、BulkUpdate、Delete 返回的字段，受影响的行扫描到 dest(struct 或 map 组成的 slice 指针)，
+// 未指定 columns 时返回全部字段，返回的受影响行数为返回的行数；
+// 数据库不支持 RETURNING 时在事务中先加锁查询再执行修改来模拟
+func (session *Session) Returning(dest interface{}, columns ...string) *Session {
+	session = session.getInstance()
+	destRefValue := reflect.ValueOf(dest)
+	if destRefValue.Kind() != reflect.Ptr || destRefValue.Elem().Kind() != reflect.Slice {
+		session.AddError(errors.New("returning destination must be a pointer to slice"))
+		return session
+	}
+
+	returning := clause.Returning{}
+	for _, column := range columns {
+		returning.Columns = append(returning.Columns, parseColumn(column))
+	}
+	session.statement.AddClause(returning)
+	session.returning = dest
+	return session
+}
+
+func (session *Session) nativeReturning() bool {
+	if supporter, ok := session.statement.Dialector.(dialects.ReturningSupporter); ok {
+		return supporter.UpdateReturning(session.queryer())
+	}
+	return session.statement.Dialector.WithReturning()
+}
+
+// 执行修改语句，设置了 Returning 时将返回的行扫描到目标中
+func (session *Session) execModify() (affected int64, err error) {
+	if session.returning == nil {
+		result, err := session.execContext(session.statement.SQL.String(), session.statement.SQLVars...)
+		if err != nil {
+			return 0, err
+		}
+		return result.RowsAffected()
+	}
+
+	rows, err := session.queryContext(session.statement.SQL.String(), session.statement.SQLVars...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	// 受影响的行数为本次扫描的行数，不包含 dest 中原有的元素
+	destRefValue := reflect.Indirect(reflect.ValueOf(session.returning))
+	scanned := destRefValue.Len()
+	if err := ScanAll(rows, DestWrapper{Dest: session.returning, ReflectValue: destRefValue}); err != nil {
+		return 0, err
+	}
+	return int64(destRefValue.Len() - scanned), nil
+}
+
+// 模拟 RETURNING: 在事务中加锁查询受影响的行，删除时直接返回查询结果，
+// 修改时先锁定主键，修改后再按主键查询最新的数据
+func (session *Session) emulateReturning(modify func(session *Session) (int64, error), isDelete bool) (affected int64, err error) {
+	dest := session.returning
+	returning, _ := session.statement.Clauses["RETURNING"].Expression.(clause.Returning)
+	delete(session.statement.Clauses, "RETURNING")
+	session.returning = nil
+
+	table := session.statement.Tables[0]
+	columns := make([]string, 0, len(returning.Columns))
+	for _, column := range returning.Columns {
+		columns = append(columns, column.Name)
+	}
+	if len(columns) == 0 {
+		if table.Alias != "" {
+			columns = append(columns, table.Alias+".*")
+		} else {
+			columns = append(columns, table.Name+".*")
+		}
+	}
+
+	run := func(db *SqlDB) (err error) {
+		query := session.Clone()
+		query.db = db
+		delete(query.statement.Clauses, "ORDER BY")
+		delete(query.statement.Clauses, "LIMIT")
+		query = query.ForUpdate()
+
+		var (
+			key  string
+			keys []interface{}
+		)
+		if isDelete {
+			err = query.Select(columns...).Find(dest)
+		} else {
+			key = session.batchKey()
+			err = query.Pluck(key, &keys)
+		}
+		if err != nil && err != ErrDryRun {
+			return err
+		}
+
+		modifySession := session.Clone()
+		modifySession.db = db
+		if affected, err = modify(modifySession); err != nil && err != ErrDryRun {
+			return err
+		}
+		if isDelete || len(keys) == 0 {
+			return nil
+		}
+
+		name := table.Name
+		if table.Alias != "" {
+			name += " AS " + table.Alias
+		}
+		result := NewSession(session.ctx, db, name)
+		result.dryRun = session.dryRun
+		err = result.Unscoped().Select(columns...).Where(clause.IN{Column: parseColumn(key), Values: keys}).Find(dest)
+		if err != nil && err != ErrDryRun {
+			return err
+		}
+		return nil
+	}
+
+	if session.db.tx != nil || session.dryRun != nil {
+		err = run(session.db)
+	} else {
+		err = session.db.TxContext(session.ctx, run)
+	}
+	return
+}
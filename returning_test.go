@@ -0,0 +1,126 @@
+package sqldb_test
+
+import (
+	"database/sql"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/dialects"
+	"github.com/binwen/sqldb/dialects/sqlite"
+	"github.com/binwen/sqldb/tests"
+)
+
+func TestReturning(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3, 4)
+
+		var users []tests.AuthUser
+		if affected, err := tests.DBEngine.Table("auth_user").Where("id < ?", 3).Returning(&users).Update("age", 30); err != nil {
+			t.Fatal(err)
+		} else if affected != 2 {
+			t.Errorf("rows affected should be `2`, got `%v`", affected)
+		}
+		if len(users) != 2 || users[0].Age != 30 || users[1].Age != 30 || users[0].UserName != "user1" {
+			t.Errorf("returning should scan updated users, got `%+v`", users)
+		}
+
+		var deleted []map[string]interface{}
+		if affected, err := tests.DBEngine.Table("auth_user").Where("id > ?", 2).Returning(&deleted, "id", "username").Delete(); err != nil {
+			t.Fatal(err)
+		} else if affected != 2 {
+			t.Errorf("rows affected should be `2`, got `%v`", affected)
+		}
+		if len(deleted) != 2 || len(deleted[0]) != 2 {
+			t.Errorf("returning should scan deleted rows with `2` columns, got `%v`", deleted)
+		}
+		if count, _ := tests.DBEngine.Table("auth_user").Count(); count != 2 {
+			t.Errorf("should remain `2` users, got `%v`", count)
+		}
+
+		var ids []int
+		if affected, err := tests.DBEngine.Table("auth_user").Where("id = ?", 100).Returning(&ids, "id").Update("age", 1); err != nil {
+			t.Error(err)
+		} else if affected != 0 || len(ids) != 0 {
+			t.Errorf("no rows should be affected, got `%v` and `%v`", affected, ids)
+		}
+
+		if affected, err := tests.DBEngine.Table("auth_user").Where("id = ?", 1).Returning(&users).Update("age", 31); err != nil {
+			t.Error(err)
+		} else if affected != 1 || len(users) != 3 {
+			t.Errorf("rows affected should not count existing elements, got `%v` and `%v` users", affected, len(users))
+		}
+
+		if _, err := tests.DBEngine.Table("auth_user").Where("id = ?", 1).Returning(users).Delete(); err == nil {
+			t.Error("returning with non-pointer destination should return error")
+		}
+	})
+}
+
+// 不支持 UPDATE、DELETE RETURNING 的 sqlite 方言，用于测试模拟 RETURNING
+type emulatedReturningDialector struct {
+	*sqlite.Dialector
+}
+
+func (emulatedReturningDialector) UpdateReturning(dialects.Queryer) bool {
+	return false
+}
+
+var registerEmulatedReturning sync.Once
+
+func TestEmulateReturning(t *testing.T) {
+	if tests.DBEngine.DriverName() != "sqlite3" {
+		t.Skip("emulated returning is tested with sqlite")
+	}
+	registerEmulatedReturning.Do(func() {
+		dialect, _ := dialects.GetDialector("sqlite3")
+		sql.Register("sqlite3_emulated_returning", &sqlite3.SQLiteDriver{})
+		dialects.RegisterDialector("sqlite3_emulated_returning", emulatedReturningDialector{dialect.(*sqlite.Dialector)})
+	})
+	dns := os.Getenv("Dns")
+	if dns == "" {
+		dns = "./sqldb.db?cache=shared&mode=rwc"
+	}
+	engine, err := sqldb.OpenDBEngine(sqldb.DBConfig{"default": &sqldb.Config{Driver: "sqlite3_emulated_returning", DNS: dns}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer engine.Close()
+
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3, 4)
+
+		statements, err := engine.Table("auth_user").ToSQL(func(s *sqldb.Session) error {
+			var users []tests.AuthUser
+			_, err := s.Where("id < ?", 3).Returning(&users).Update("age", 30)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(statements) < 2 || !strings.HasPrefix(statements[0].SQL, "SELECT") || strings.Contains(statements[1].SQL, "RETURNING") {
+			t.Fatalf("returning should be emulated, got `%v`", statements)
+		}
+
+		users := []tests.AuthUser{{}}
+		if affected, err := engine.Table("auth_user").Where("id < ?", 3).Returning(&users).Update("age", 30); err != nil {
+			t.Fatal(err)
+		} else if affected != 2 || len(users) != 3 || users[1].Age != 30 || users[2].Age != 30 {
+			t.Errorf("emulated returning should scan updated users, got `%v` `%+v`", affected, users)
+		}
+
+		var deleted []map[string]interface{}
+		if affected, err := engine.Table("auth_user").Where("id > ?", 2).Returning(&deleted, "id").Delete(); err != nil {
+			t.Fatal(err)
+		} else if affected != 2 || len(deleted) != 2 {
+			t.Errorf("emulated returning should scan deleted rows, got `%v` `%v`", affected, deleted)
+		}
+		if count, _ := tests.DBEngine.Table("auth_user").Count(); count != 2 {
+			t.Errorf("should remain `2` users, got `%v`", count)
+		}
+	})
+}
@@ -0,0 +1,146 @@
+package sqldb
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// 流式读取的结果集，逐行扫描，与 ScanAll 使用相同的映射与空值处理
+type Rows struct {
+	rows *sqlx.Rows
+	err  error
+}
+
+func newRows(rows *sqlx.Rows, err error) *Rows {
+	return &Rows{rows: rows, err: err}
+}
+
+func (r *Rows) Next() bool {
+	if r.err != nil || r.rows == nil {
+		return false
+	}
+	return r.rows.Next()
+}
+
+// 扫描当前行，dest 可以为 struct 指针、map[string]interface{}(或其指针) 及其他单一字段类型的指针
+func (r *Rows) Scan(dest interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	switch values := dest.(type) {
+	case map[string]interface{}:
+		if values == nil {
+			return errors.New("nil map passed to scan destination")
+		}
+		return ParseMapScan(r.rows, values)
+	case *map[string]interface{}:
+		if *values == nil {
+			*values = map[string]interface{}{}
+		}
+		return ParseMapScan(r.rows, *values)
+	}
+
+	destRefValue := reflect.ValueOf(dest)
+	if destRefValue.Kind() != reflect.Ptr || destRefValue.IsNil() {
+		return errors.New("must pass a non-nil pointer to scan destination")
+	}
+
+	if reflect.Indirect(destRefValue).Kind() == reflect.Struct {
+		return r.rows.StructScan(dest)
+	}
+	return r.rows.Scan(dest)
+}
+
+func (r *Rows) ScanMap() (map[string]interface{}, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return MakeMapScan(r.rows)
+}
+
+func (r *Rows) Columns() ([]string, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.rows.Columns()
+}
+
+func (r *Rows) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.rows.Err()
+}
+
+func (r *Rows) Close() error {
+	if r.rows == nil {
+		return nil
+	}
+	return r.rows.Close()
+}
+
+// 以 channel 方式逐行读取，每行扫描到 newDest 返回的新对象中；
+// ctx 取消或读取出错时停止，错误写入 error channel，两个 channel 在结束后均会关闭，结果集也会自动关闭
+func (r *Rows) Chan(ctx context.Context, newDest func() interface{}) (<-chan interface{}, <-chan error) {
+	out := make(chan interface{})
+	errc := make(chan error, 1)
+
+	go func() {
+		defer close(errc)
+		defer close(out)
+		defer r.Close()
+
+		for r.Next() {
+			if err := ctx.Err(); err != nil {
+				errc <- err
+				return
+			}
+
+			dest := newDest()
+			if err := r.Scan(dest); err != nil {
+				errc <- err
+				return
+			}
+
+			select {
+			case out <- dest:
+			case <-ctx.Done():
+				errc <- ctx.Err()
+				return
+			}
+		}
+
+		if err := r.Err(); err != nil {
+			errc <- err
+		}
+	}()
+
+	return out, errc
+}
+
+func (session *Session) Iterate() *Rows {
+	session = session.getInstance()
+	defer session.Clear()
+	if session.Error != nil {
+		return newRows(nil, session.Error)
+	}
+
+	if session.statement.SQL.String() == "" {
+		if err := session.buildQuerySQL(); err != nil {
+			return newRows(nil, err)
+		}
+	}
+
+	return newRows(session.queryContext(session.statement.SQL.String(), session.statement.SQLVars...))
+}
+
+func (raw *RawSession) Iterate() *Rows {
+	if raw.err != nil {
+		return newRows(nil, raw.err)
+	}
+	return newRows(raw.db.QueryContext(raw.ctx, raw.query, raw.vars...))
+}
@@ -0,0 +1,87 @@
+package sqldb_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/binwen/sqldb/tests"
+)
+
+func TestIterate(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3)
+		rows := tests.DBEngine.Table("auth_user").Select("id, username").Iterate()
+		defer rows.Close()
+		var users []tests.SimpleAuthUser
+		for rows.Next() {
+			var user tests.SimpleAuthUser
+			if err := rows.Scan(&user); err != nil {
+				t.Fatal(err)
+			}
+			users = append(users, user)
+		}
+		if err := rows.Err(); err != nil {
+			t.Error(err)
+		}
+		if len(users) != 3 || users[2].UserName != "user3" {
+			t.Errorf("iterate users should be `3` rows, got `%v`", users)
+		}
+
+		rows = tests.DBEngine.Raw("select id, last_login from auth_user where id = ?", 2).Iterate()
+		defer rows.Close()
+		if !rows.Next() {
+			t.Fatal("iterate should return one row")
+		}
+		if m, err := rows.ScanMap(); err != nil {
+			t.Error(err)
+		} else if m["id"].(int64) != 2 {
+			t.Errorf("id value should `2`, got `%v`", m["id"])
+		}
+
+		var id int
+		rows = tests.DBEngine.Table("auth_user").Select("id").Where("id = ?", 3).Iterate()
+		for rows.Next() {
+			if err := rows.Scan(&id); err != nil {
+				t.Error(err)
+			}
+		}
+		rows.Close()
+		if id != 3 {
+			t.Errorf("id value should `3`, got `%v`", id)
+		}
+
+		rows = tests.DBEngine.Table("auth_user").Where(1).Iterate()
+		if rows.Next() || rows.Err() == nil {
+			t.Error("iterate with invalid session should return error")
+		}
+	})
+}
+
+func TestIterateChan(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3, 4)
+		out, errc := tests.DBEngine.Table("auth_user").Iterate().Chan(context.Background(), func() interface{} {
+			return &tests.AuthUser{}
+		})
+		var ids []int
+		for item := range out {
+			ids = append(ids, item.(*tests.AuthUser).Id)
+		}
+		if err := <-errc; err != nil {
+			t.Error(err)
+		}
+		if len(ids) != 4 {
+			t.Errorf("channel should receive `4` rows, got `%v`", ids)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		out, errc = tests.DBEngine.Table("auth_user").Iterate().Chan(ctx, func() interface{} {
+			return map[string]interface{}{}
+		})
+		<-out
+		cancel()
+		if err := <-errc; err != context.Canceled {
+			t.Errorf("cancelled iterate should return context.Canceled, got `%v`", err)
+		}
+	})
+}
@@ -1,8 +1,10 @@
 package sqldb
 
 import (
+	"database/sql"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -33,7 +35,18 @@ func ParseMapScan(rows *sqlx.Rows, mapValue map[string]interface{}) error {
 	return nil
 }
 
+// 扫描全部结果到目标中，目标为实现了 AfterFindHook 的 struct 时扫描后调用钩子
 func ScanAll(rows *sqlx.Rows, dest DestWrapper) error {
+	if err := scanAll(rows, dest); err != nil {
+		return err
+	}
+	if dest.db != nil && hasHooks(dest.ReflectValue, afterFind) {
+		return callHooks(dest.ctx, dest.db, dest.ReflectValue, afterFind)
+	}
+	return nil
+}
+
+func scanAll(rows *sqlx.Rows, dest DestWrapper) error {
 	switch values := dest.Dest.(type) {
 	case map[string]interface{}, *map[string]interface{}:
 		mapValue, ok := values.(map[string]interface{})
@@ -153,3 +166,77 @@ func ScanAll(rows *sqlx.Rows, dest DestWrapper) error {
 	}
 	return rows.Close()
 }
+
+// 可能为 NULL 的单值扫描目标：值为 NULL 时不修改 dest，否则按 database/sql 的规则转换后写入 dest
+type nullableScanner struct {
+	dest  interface{}
+	valid bool
+}
+
+func (s *nullableScanner) Scan(src interface{}) error {
+	if scanner, ok := s.dest.(sql.Scanner); ok {
+		s.valid = src != nil
+		return scanner.Scan(src)
+	}
+	if src == nil {
+		return nil
+	}
+	s.valid = true
+
+	if v, ok := s.dest.(*interface{}); ok {
+		if b, ok := src.([]byte); ok {
+			src = string(b)
+		}
+		*v = src
+		return nil
+	}
+
+	dest := reflect.ValueOf(s.dest)
+	if dest.Kind() != reflect.Ptr || dest.IsNil() {
+		return fmt.Errorf("must pass a pointer, not a value, to scan destination, got %T", s.dest)
+	}
+	dest = dest.Elem()
+
+	switch dest.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var v sql.NullInt64
+		if err := v.Scan(src); err != nil {
+			return err
+		}
+		dest.SetInt(v.Int64)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var v sql.NullInt64
+		if err := v.Scan(src); err != nil {
+			return err
+		}
+		dest.SetUint(uint64(v.Int64))
+	case reflect.Float32, reflect.Float64:
+		var v sql.NullFloat64
+		if err := v.Scan(src); err != nil {
+			return err
+		}
+		dest.SetFloat(v.Float64)
+	case reflect.String:
+		var v sql.NullString
+		if err := v.Scan(src); err != nil {
+			return err
+		}
+		dest.SetString(v.String)
+	case reflect.Bool:
+		var v sql.NullBool
+		if err := v.Scan(src); err != nil {
+			return err
+		}
+		dest.SetBool(v.Bool)
+	default:
+		if dest.Type() != reflect.TypeOf(time.Time{}) {
+			return fmt.Errorf("unsupported scan destination %T", s.dest)
+		}
+		var v sql.NullTime
+		if err := v.Scan(src); err != nil {
+			return err
+		}
+		dest.Set(reflect.ValueOf(v.Time))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package sqldb
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/binwen/sqldb/clause"
+)
+
+type ScopeFunc func(session *Session) *Session
+
+var (
+	scopeMu             sync.RWMutex
+	defaultScopeMapping = map[string][]ScopeFunc{}
+	namedScopeMapping   = map[string]map[string]ScopeFunc{}
+)
+
+// 注册表(或表别名)的默认作用域，查询、修改、删除时自动应用，可通过 Unscoped 忽略
+func RegisterDefaultScope(table string, fns ...ScopeFunc) {
+	scopeMu.Lock()
+	defer scopeMu.Unlock()
+	defaultScopeMapping[table] = append(defaultScopeMapping[table], fns...)
+}
+
+// 注册表(或表别名)的命名作用域，通过 Session.Scope(name) 使用
+func RegisterScope(table string, name string, fn ScopeFunc) {
+	scopeMu.Lock()
+	defer scopeMu.Unlock()
+	if _, ok := namedScopeMapping[table]; !ok {
+		namedScopeMapping[table] = map[string]ScopeFunc{}
+	}
+	namedScopeMapping[table][name] = fn
+}
+
+// 移除表(或表别名)注册的默认作用域及命名作用域
+func UnregisterScopes(table string) {
+	scopeMu.Lock()
+	defer scopeMu.Unlock()
+	delete(defaultScopeMapping, table)
+	delete(namedScopeMapping, table)
+}
+
+// 查找命名作用域，作用域函数在锁外执行
+func lookupScope(table clause.Table, name string) (ScopeFunc, bool) {
+	scopeMu.RLock()
+	defer scopeMu.RUnlock()
+	fn, ok := namedScopeMapping[table.Name][name]
+	if !ok && table.Alias != "" {
+		fn, ok = namedScopeMapping[table.Alias][name]
+	}
+	return fn, ok
+}
+
+// 在当前会话上应用作用域函数，写时复制模式下作用域内的修改也作用在同一个会话上
+func (session *Session) applyScopes(fns ...ScopeFunc) {
+	immutable := session.immutable
+	session.immutable = false
+	defer func() {
+		session.immutable = immutable
+	}()
+
+	for _, fn := range fns {
+		if s := fn(session); s != nil && s != session {
+			session.statement = s.statement
+			session.AddError(s.Error)
+		}
+	}
+}
+
+// 应用默认作用域及软删除过滤，已有的 OR 条件先合并为一组，作用域条件约束所有分支；
+// 作用域中的错误记录在 session.Error 中
+func (session *Session) applyDefaultScopes() {
+	if session.unscoped || len(session.statement.Tables) == 0 {
+		return
+	}
+
+	table := session.statement.Tables[0]
+	scopeMu.RLock()
+	fns := defaultScopeMapping[table.Name]
+	if table.Alias != "" {
+		fns = append(fns[:len(fns):len(fns)], defaultScopeMapping[table.Alias]...)
+	}
+	scopeMu.RUnlock()
+	if len(fns) > 0 {
+		session.statement.groupWhere()
+		session.applyScopes(fns...)
+	}
+	session.applySoftDelete()
+	session.unscoped = true
+}
+
+func (session *Session) Scopes(fns ...ScopeFunc) *Session {
+	session = session.getInstance()
+	session.applyScopes(fns...)
+	return session
+}
+
+// 应用已注册的命名作用域
+func (session *Session) Scope(names ...string) *Session {
+	session = session.getInstance()
+	if len(session.statement.Tables) == 0 {
+		return session
+	}
+
+	table := session.statement.Tables[0]
+	for _, name := range names {
+		fn, ok := lookupScope(table, name)
+		if !ok {
+			session.AddError(fmt.Errorf("scope `%s` of table `%s` is not registered", name, table.Name))
+			continue
+		}
+		session.applyScopes(fn)
+	}
+	return session
+}
+
+// 忽略默认作用域及软删除过滤，软删除的表调用 Delete 时将物理删除
+func (session *Session) Unscoped() *Session {
+	session = session.getInstance()
+	session.unscoped = true
+	return session
+}
@@ -0,0 +1,112 @@
+package sqldb_test
+
+import (
+	"testing"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/clause"
+	"github.com/binwen/sqldb/tests"
+)
+
+func AgeBetween(min, max int) sqldb.ScopeFunc {
+	return func(session *sqldb.Session) *sqldb.Session {
+		return session.Where("age >= ?", min).Where("age <= ?", max)
+	}
+}
+
+func TestScopes(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3, 4)
+		if _, err := tests.DBEngine.Table("auth_user").Where("id in ?", []int{1, 2}).Update("age", 30); err != nil {
+			t.Fatal(err)
+		}
+
+		notFirst := func(session *sqldb.Session) *sqldb.Session {
+			return session.Not("id", 1)
+		}
+		if count, err := tests.DBEngine.Table("auth_user").Scopes(AgeBetween(20, 40), notFirst).Count(); err != nil {
+			t.Error(err)
+		} else if count != 1 {
+			t.Errorf("scoped count should be `1`, got `%v`", count)
+		}
+
+		defer sqldb.UnregisterScopes("auth_user")
+		sqldb.RegisterScope("auth_user", "young", AgeBetween(0, 20))
+		sqldb.RegisterScope("auth_user", "high_id", func(session *sqldb.Session) *sqldb.Session {
+			return session.AddClause(clause.Gt{Column: "id", Value: 3})
+		})
+		if count, err := tests.DBEngine.Table("auth_user").Scope("young", "high_id").Count(); err != nil {
+			t.Error(err)
+		} else if count != 1 {
+			t.Errorf("named scoped count should be `1`, got `%v`", count)
+		}
+
+		if _, err := tests.DBEngine.Table("auth_user").Scope("missing").Count(); err == nil {
+			t.Error("unregistered scope should return error")
+		}
+	})
+}
+
+func TestDefaultScopes(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3, 4)
+		defer sqldb.UnregisterScopes("scoped_user")
+		sqldb.RegisterDefaultScope("scoped_user", func(session *sqldb.Session) *sqldb.Session {
+			return session.Where("id > ?", 2)
+		})
+
+		var users []tests.SimpleAuthUser
+		if err := tests.DBEngine.Table("auth_user as scoped_user").Find(&users); err != nil {
+			t.Error(err)
+		} else if len(users) != 2 {
+			t.Errorf("default scoped users should be `2`, got `%v`", len(users))
+		}
+
+		if count, err := tests.DBEngine.Table("auth_user as scoped_user").Unscoped().Count(); err != nil {
+			t.Error(err)
+		} else if count != 4 {
+			t.Errorf("unscoped count should be `4`, got `%v`", count)
+		}
+
+		base := tests.DBEngine.Table("auth_user as scoped_user").Immutable()
+		if count, err := base.Where("id < ?", 4).Count(); err != nil {
+			t.Error(err)
+		} else if count != 1 {
+			t.Errorf("immutable default scoped count should be `1`, got `%v`", count)
+		}
+
+		if count, err := tests.DBEngine.Table("auth_user as scoped_user").Where("id = ?", 1).Or("id = ?", 3).Count(); err != nil {
+			t.Error(err)
+		} else if count != 1 {
+			t.Errorf("default scope should apply to every OR branch, count should be `1`, got `%v`", count)
+		}
+		if affected, err := tests.DBEngine.Table("auth_user as scoped_user").Where("id = ?", 1).Or("id = ?", 3).Update("age", 2); err != nil {
+			t.Error(err)
+		} else if affected != 1 {
+			t.Errorf("default scoped update should apply to every OR branch, affected should be `1`, got `%v`", affected)
+		}
+
+		if affected, err := tests.DBEngine.Table("auth_user").Where("id < ?", 4).Update("age", 1); err != nil {
+			t.Error(err)
+		} else if affected != 3 {
+			t.Errorf("rows affected without default scope should be `3`, got `%v`", affected)
+		}
+
+		defer sqldb.UnregisterScopes("failed_user")
+		sqldb.RegisterDefaultScope("failed_user", func(session *sqldb.Session) *sqldb.Session {
+			return session.Where([]int{1})
+		})
+		var failed []tests.SimpleAuthUser
+		if err := tests.DBEngine.Table("auth_user as failed_user").Find(&failed); err == nil {
+			t.Errorf("failed default scope should return error, got `%v` users", len(failed))
+		}
+		if count, err := tests.DBEngine.Table("auth_user as failed_user").Count(); err == nil {
+			t.Errorf("failed default scope should return error, got count `%v`", count)
+		}
+
+		sqldb.UnregisterScopes("scoped_user")
+		if count, err := tests.DBEngine.Table("auth_user as scoped_user").Count(); err != nil || count != 4 {
+			t.Errorf("unregistered default scope should not be applied, got `%v` `%v`", count, err)
+		}
+	})
+}
@@ -2,15 +2,20 @@ package sqldb
 
 import (
 	"context"
+	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
+	"math"
 	"reflect"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/jmoiron/sqlx"
 
 	"github.com/binwen/sqldb/clause"
+	"github.com/binwen/sqldb/dialects"
 )
 
 var mapper = NewReflectMapperFunc("db", strings.ToLower)
@@ -20,11 +25,22 @@ type Session struct {
 	db        *SqlDB
 	statement *Statement
 	ctx       context.Context
+	cursor    *pageCursor     // 游标分页的起始位置，由 After 设置
+	dryRun    *[]SQLStatement // dry run 模式下记录的语句，见 ToSQL
+	immutable bool            // 写时复制模式，见 Immutable
+	unscoped  bool            // 忽略默认作用域，见 Unscoped
+	returning interface{}     // 修改、删除时返回的行的目标，见 Returning
+	trashed   trashedMode     // 是否包含已软删除的行，见 WithTrashed、OnlyTrashed
+	model     interface{}     // 修改、删除时调用钩子的模型，见 Model
+	skipHooks bool            // 已在钩子的事务中执行，不再调用钩子
+	preloads  []preload       // 查询后预加载的关联字段，见 Preload
 }
 
 type DestWrapper struct {
 	Dest         interface{}
 	ReflectValue reflect.Value
+	ctx          context.Context // 设置后扫描完成时调用 AfterFind 钩子
+	db           *SqlDB
 }
 
 func Expr(expr string, args ...interface{}) *clause.Expr {
@@ -69,6 +85,7 @@ func (session *Session) AddError(err error) {
 }
 
 func (session *Session) AddClause(conds ...clause.Expression) *Session {
+	session = session.getInstance()
 	var whereConds []interface{}
 	for _, cond := range conds {
 		if c, ok := cond.(clause.IClause); ok {
@@ -93,6 +110,7 @@ func (session *Session) AddClause(conds ...clause.Expression) *Session {
 }
 
 func (session *Session) Select(columns ...string) *Session {
+	session = session.getInstance()
 	if columns == nil {
 		session.statement.AddClause(clause.Select{})
 		return session
@@ -118,31 +136,47 @@ func (session *Session) Select(columns ...string) *Session {
 }
 
 func (session *Session) SelectExpr(query string, args ...interface{}) *Session {
+	session = session.getInstance()
 	session.statement.AddClause(clause.Select{Expressions: []clause.Expression{clause.Expr{SQL: query, Vars: args}}})
 	return session
 }
 
 func (session *Session) Distinct(columns ...string) *Session {
+	session = session.getInstance()
 	session.statement.AddClause(clause.Select{Distinct: true})
 	return session.Select(columns...)
 }
 
 func (session *Session) Limit(limit int) *Session {
+	session = session.getInstance()
 	session.statement.AddClause(clause.Limit{Limit: limit})
 	return session
 }
 
 func (session *Session) Offset(offset int) *Session {
+	session = session.getInstance()
 	session.statement.AddClause(clause.Limit{Offset: offset})
 	return session
 }
 
-func (session *Session) GroupBy(name string) *Session {
-	session.statement.AddClause(clause.GroupBy{Columns: []clause.Column{{Name: name}}})
+// 分组字段，支持多个字段，如: GroupBy("status", "age") 或 GroupBy("status, age")；
+// 括号及引号内的逗号不作为分隔符，如: GroupBy("DATE_FORMAT(created_at, '%Y-%m')")
+func (session *Session) GroupBy(names ...string) *Session {
+	session = session.getInstance()
+	groupBy := clause.GroupBy{}
+	for _, name := range names {
+		for _, col := range splitColumns(name) {
+			if col = strings.TrimSpace(col); col != "" {
+				groupBy.Columns = append(groupBy.Columns, parseColumn(col))
+			}
+		}
+	}
+	session.statement.AddClause(groupBy)
 	return session
 }
 
 func (session *Session) Having(query interface{}, args ...interface{}) *Session {
+	session = session.getInstance()
 	conditions, err := session.statement.BuildCondition(query, args...)
 	if err != nil {
 		session.AddError(err)
@@ -154,6 +188,7 @@ func (session *Session) Having(query interface{}, args ...interface{}) *Session
 }
 
 func (session *Session) OrderBy(order string) *Session {
+	session = session.getInstance()
 	session.statement.AddClause(clause.OrderBy{
 		Columns: []clause.OrderByColumn{{Column: clause.Column{Name: order, Raw: true}}},
 	})
@@ -163,6 +198,7 @@ func (session *Session) OrderBy(order string) *Session {
 
 // 降序字段
 func (session *Session) Desc(columns ...string) *Session {
+	session = session.getInstance()
 	order := clause.OrderBy{}
 	for _, column := range columns {
 		order.Columns = append(order.Columns, clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: true})
@@ -173,6 +209,7 @@ func (session *Session) Desc(columns ...string) *Session {
 
 // 升序字段
 func (session *Session) Asc(columns ...string) *Session {
+	session = session.getInstance()
 	order := clause.OrderBy{}
 	for _, column := range columns {
 		order.Columns = append(order.Columns, clause.OrderByColumn{Column: clause.Column{Name: column}, Desc: false})
@@ -182,6 +219,7 @@ func (session *Session) Asc(columns ...string) *Session {
 }
 
 func (session *Session) Where(query interface{}, args ...interface{}) *Session {
+	session = session.getInstance()
 	conditions, err := session.statement.BuildCondition(query, args...)
 	if err != nil {
 		session.AddError(err)
@@ -192,6 +230,7 @@ func (session *Session) Where(query interface{}, args ...interface{}) *Session {
 }
 
 func (session *Session) Not(query interface{}, args ...interface{}) *Session {
+	session = session.getInstance()
 	conditions, err := session.statement.BuildCondition(query, args...)
 	if err != nil {
 		session.AddError(err)
@@ -203,6 +242,7 @@ func (session *Session) Not(query interface{}, args ...interface{}) *Session {
 }
 
 func (session *Session) Or(query interface{}, args ...interface{}) *Session {
+	session = session.getInstance()
 	conditions, err := session.statement.BuildCondition(query, args...)
 	if err != nil {
 		session.AddError(err)
@@ -214,20 +254,32 @@ func (session *Session) Or(query interface{}, args ...interface{}) *Session {
 }
 
 func (session *Session) Join(condition string, args ...interface{}) *Session {
+	session = session.getInstance()
 	session.statement.AddClause(clause.From{
 		Joins: []clause.Join{{Expression: clause.Expr{SQL: condition, Vars: args}}},
 	})
 	return session
 }
 
-func (session *Session) buildQuerySQL() {
-	session.statement.SQL.Grow(100)
+func (session *Session) prepareQuery() {
+	session.applyDefaultScopes()
 	if f, ok := session.statement.Clauses["FROM"].Expression.(clause.From); !ok || len(f.Tables) == 0 {
 		session.statement.AddClause(clause.From{Tables: session.statement.Tables})
 	}
 
 	session.statement.AddClauseIfNotExists(clause.Select{})
+	session.translateLocks()
+}
+
+// 构建查询语句，默认作用域中的错误在构建后返回
+func (session *Session) buildQuerySQL() error {
+	session.prepareQuery()
+	if session.Error != nil {
+		return session.Error
+	}
+	session.statement.SQL.Grow(100)
 	session.statement.Build("HINT", "SELECT", "FROM", "WHERE", "GROUP BY", "ORDER BY", "LIMIT", "FOR")
+	return nil
 }
 
 func (session *Session) execQuery(dest DestWrapper) (err error) {
@@ -236,10 +288,12 @@ func (session *Session) execQuery(dest DestWrapper) (err error) {
 	}
 
 	if session.statement.SQL.String() == "" {
-		session.buildQuerySQL()
+		if err := session.buildQuerySQL(); err != nil {
+			return err
+		}
 	}
 
-	rows, err := session.db.QueryContext(session.ctx, session.statement.SQL.String(), session.statement.SQLVars...)
+	rows, err := session.queryContext(session.statement.SQL.String(), session.statement.SQLVars...)
 	if err != nil {
 		return err
 	}
@@ -247,30 +301,48 @@ func (session *Session) execQuery(dest DestWrapper) (err error) {
 	return ScanAll(rows, dest)
 }
 
+// 查询完成后先预加载关联字段，再调用 AfterFind 钩子，钩子中可以使用预加载的数据
+func (session *Session) afterQuery(dest reflect.Value) error {
+	if err := session.loadPreloads(dest); err != nil {
+		return err
+	}
+	if hasHooks(dest, afterFind) {
+		return callHooks(session.ctx, session.db, dest, afterFind)
+	}
+	return nil
+}
+
 func (session *Session) Find(dest interface{}) error {
+	session = session.getInstance()
 	defer session.Clear()
 	destRefValue := reflect.ValueOf(dest)
 	if destRefValue.Kind() != reflect.Ptr {
 		return errors.New("must pass a pointer, not a value, to scan destination")
 	}
 	destWrapper := DestWrapper{Dest: dest, ReflectValue: reflect.Indirect(destRefValue)}
-
-	return session.execQuery(destWrapper)
+	if err := session.execQuery(destWrapper); err != nil {
+		return err
+	}
+	return session.afterQuery(destWrapper.ReflectValue)
 }
 
 func (session *Session) First(dest interface{}) error {
+	session = session.getInstance()
 	defer session.Clear()
 	destRefValue := reflect.Indirect(reflect.ValueOf(dest))
 	if IsNil(destRefValue) {
 		return fmt.Errorf("nil pointer passed to scan destination, gov `%v`", dest)
 	}
 	destWrapper := DestWrapper{Dest: dest, ReflectValue: destRefValue}
-	session.Limit(1)
-
-	return session.execQuery(destWrapper)
+	session = session.Limit(1)
+	if err := session.execQuery(destWrapper); err != nil {
+		return err
+	}
+	return session.afterQuery(destWrapper.ReflectValue)
 }
 
 func (session *Session) Count() (count int64, err error) {
+	session = session.getInstance()
 	defer session.Clear()
 	if session.Error != nil {
 		return count, session.Error
@@ -281,15 +353,17 @@ func (session *Session) Count() (count int64, err error) {
 	}
 
 	if session.statement.SQL.String() == "" {
-		session.buildQuerySQL()
+		if err := session.buildQuerySQL(); err != nil {
+			return count, err
+		}
 	}
 
-	r := session.db.QueryRowContext(session.ctx, session.statement.SQL.String(), session.statement.SQLVars...)
-	err = r.Scan(&count)
+	err = session.queryRowScan(session.statement.SQL.String(), session.statement.SQLVars, &count)
 	return count, err
 }
 
 func (session *Session) Exist() (bool, error) {
+	session = session.getInstance()
 	defer session.Clear()
 	count, err := session.Count()
 	if err != nil {
@@ -298,6 +372,215 @@ func (session *Session) Exist() (bool, error) {
 	return count > 0, nil
 }
 
+func parseColumn(column string) clause.Column {
+	fields := strings.FieldsFunc(column, IsChar)
+	if len(fields) == 1 && fields[0] == strings.TrimSpace(column) {
+		return clause.Column{Name: fields[0]}
+	}
+	return clause.Column{Name: column, Raw: true}
+}
+
+// 按括号及引号外的逗号拆分字段列表
+func splitColumns(columns string) []string {
+	var (
+		parts []string
+		depth int
+		quote rune
+		start int
+	)
+	for i, c := range columns {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, columns[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, columns[start:])
+}
+
+// 聚合查询，结果扫描到 dest 中；结果为 NULL(如空结果集)时返回 false 且不修改 dest，
+// dest 为 sql.Null* 等 sql.Scanner 时由其自行处理 NULL
+func (session *Session) aggregate(function string, column string, dest interface{}) (valid bool, err error) {
+	defer session.Clear()
+	if session.Error != nil {
+		return false, session.Error
+	}
+	if _, ok := session.statement.Clauses["GROUP BY"]; ok {
+		return false, fmt.Errorf("%s with GROUP BY returns one value per group, use GroupAggregate instead", function)
+	}
+
+	delete(session.statement.Clauses, "SELECT")
+	session.statement.AddClause(clause.Select{
+		Expressions: []clause.Expression{clause.Expr{SQL: function + "(?)", Vars: []interface{}{parseColumn(column)}}},
+	})
+
+	if session.statement.SQL.String() == "" {
+		if err := session.buildQuerySQL(); err != nil {
+			return false, err
+		}
+	}
+
+	result := &nullableScanner{dest: dest}
+	if err = session.queryRowScan(session.statement.SQL.String(), session.statement.SQLVars, result); err != nil {
+		return false, err
+	}
+	return result.valid, nil
+}
+
+// 如: var total float64; ok, err := db.Table("orders").Sum("amount", &total)
+func (session *Session) Sum(column string, dest interface{}) (bool, error) {
+	session = session.getInstance()
+	return session.aggregate("SUM", column, dest)
+}
+
+func (session *Session) Avg(column string, dest interface{}) (bool, error) {
+	session = session.getInstance()
+	return session.aggregate("AVG", column, dest)
+}
+
+// dest 可以为数值、字符串、time.Time 等类型，如: var first time.Time; db.Table("auth_user").Min("date_joined", &first)
+func (session *Session) Min(column string, dest interface{}) (bool, error) {
+	session = session.getInstance()
+	return session.aggregate("MIN", column, dest)
+}
+
+func (session *Session) Max(column string, dest interface{}) (bool, error) {
+	session = session.getInstance()
+	return session.aggregate("MAX", column, dest)
+}
+
+// 统一各驱动返回的值：MySQL 文本协议以 []byte 返回所有值，按字段类型将整数转换为 int64、
+// 小数转换为 float64，其余转换为 string
+func normalizeValue(value interface{}, columnType *sql.ColumnType) interface{} {
+	b, ok := value.([]byte)
+	if !ok {
+		return value
+	}
+
+	typeName := strings.ToUpper(columnType.DatabaseTypeName())
+	switch {
+	case strings.Contains(typeName, "INT"):
+		if i, err := strconv.ParseInt(string(b), 10, 64); err == nil {
+			return i
+		}
+	case strings.Contains(typeName, "DECIMAL"), strings.Contains(typeName, "NUMERIC"),
+		strings.Contains(typeName, "FLOAT"), strings.Contains(typeName, "DOUBLE"), strings.Contains(typeName, "REAL"):
+		if f, err := strconv.ParseFloat(string(b), 64); err == nil {
+			return f
+		}
+	}
+	return string(b)
+}
+
+// 分组聚合结果，Keys 与分组字段顺序一致，数值统一为 int64 或 float64
+type GroupResult struct {
+	Keys  []interface{}
+	Value interface{}
+}
+
+// 分组聚合查询，dest 可以为 *map[K]V(仅限单一分组字段) 或 *[]GroupResult，
+// 如: var m map[string]int64; GroupBy("username").GroupAggregate("count(*)", &m)
+func (session *Session) GroupAggregate(expr string, dest interface{}) error {
+	session = session.getInstance()
+	defer session.Clear()
+	if session.Error != nil {
+		return session.Error
+	}
+
+	groupBy, ok := session.statement.Clauses["GROUP BY"].Expression.(clause.GroupBy)
+	if !ok || len(groupBy.Columns) == 0 {
+		return errors.New("group aggregate must be used with GROUP BY columns")
+	}
+
+	destRefValue := reflect.ValueOf(dest)
+	if destRefValue.Kind() != reflect.Ptr || IsNil(destRefValue) {
+		return errors.New("must pass a pointer, not a value, to scan destination")
+	}
+	destValue := destRefValue.Elem()
+
+	results, isResults := dest.(*[]GroupResult)
+	if !isResults {
+		if destValue.Kind() != reflect.Map {
+			return fmt.Errorf("group aggregate destination must be `*map` or `*[]GroupResult`, got %T", dest)
+		}
+		if len(groupBy.Columns) != 1 {
+			return fmt.Errorf("group aggregate into map needs exactly one GROUP BY column, got %d", len(groupBy.Columns))
+		}
+		if destValue.IsNil() {
+			destValue.Set(reflect.MakeMap(destValue.Type()))
+		}
+	}
+
+	delete(session.statement.Clauses, "SELECT")
+	session.statement.AddClause(clause.Select{
+		Columns:     groupBy.Columns,
+		Expressions: []clause.Expression{clause.Expr{SQL: expr}},
+	})
+	if err := session.buildQuerySQL(); err != nil {
+		return err
+	}
+
+	rows, err := session.queryContext(session.statement.SQL.String(), session.statement.SQLVars...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columnTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return err
+	}
+
+	for rows.Next() {
+		if isResults {
+			values := make([]interface{}, len(groupBy.Columns)+1)
+			scanArgs := make([]interface{}, len(values))
+			for i := range values {
+				scanArgs[i] = &values[i]
+			}
+			if err := rows.Scan(scanArgs...); err != nil {
+				return err
+			}
+			for i, v := range values {
+				values[i] = normalizeValue(v, columnTypes[i])
+			}
+			*results = append(*results, GroupResult{Keys: values[:len(values)-1], Value: values[len(values)-1]})
+		} else {
+			key := reflect.New(destValue.Type().Key())
+			value := reflect.New(destValue.Type().Elem())
+			if err := rows.Scan(key.Interface(), value.Interface()); err != nil {
+				return err
+			}
+			destValue.SetMapIndex(key.Elem(), value.Elem())
+		}
+	}
+	return rows.Err()
+}
+
+// 查询单一字段到slice中，如: var ids []int; Pluck("id", &ids)
+func (session *Session) Pluck(column string, dest interface{}) error {
+	session = session.getInstance()
+	destRefValue := reflect.ValueOf(dest)
+	if destRefValue.Kind() != reflect.Ptr || destRefValue.Elem().Kind() != reflect.Slice {
+		session.Clear()
+		return fmt.Errorf("pluck destination must be a pointer to slice, got %T", dest)
+	}
+
+	delete(session.statement.Clauses, "SELECT")
+	session.statement.AddClause(clause.Select{Columns: []clause.Column{parseColumn(column)}})
+	return session.Find(dest)
+}
+
 func convertCreateValues(dataRefValue reflect.Value, data interface{}) (values clause.Values) {
 	switch data.(type) {
 	case map[string]interface{}, *map[string]interface{}:
@@ -361,16 +644,20 @@ func convertCreateValues(dataRefValue reflect.Value, data interface{}) (values c
 
 			switch directType.Kind() {
 			case reflect.Struct:
+				// 整数字段(如自增主键)在所有行中均为零值时才省略，否则按实际值写入，不能把零值写成 NULL
+				fieldMaps := make([]map[string]reflect.Value, dataLen)
 				for i := 0; i < dataLen; i++ {
-					for field, value := range mapper.FieldMap(dataRefValue.Index(i)) {
-						if IsIntZero(value) {
-							continue
-						}
-						if _, ok := columnDataMap[field]; !ok {
+					fieldMaps[i] = mapper.FieldMap(dataRefValue.Index(i))
+					for field, value := range fieldMaps[i] {
+						if _, ok := columnDataMap[field]; !ok && !IsIntZero(value) {
 							columnDataMap[field] = make([]interface{}, dataLen)
 							columns = append(columns, field)
 						}
-						columnDataMap[field][i] = reflect.Indirect(value).Interface()
+					}
+				}
+				for i, fields := range fieldMaps {
+					for field, data := range columnDataMap {
+						data[i] = reflect.Indirect(fields[field]).Interface()
 					}
 				}
 			case reflect.Map:
@@ -416,29 +703,31 @@ type ExecResult struct {
 	affected int64
 }
 
-func (session *Session) insert(isBulk bool, dataRefValue reflect.Value, data interface{}) *ExecResult {
+func (session *Session) insert(values clause.Values) *ExecResult {
 	session.statement.AddClauseIfNotExists(clause.Insert{Table: clause.Table{Name: session.statement.Tables[0].Name}})
-	session.statement.AddClause(convertCreateValues(dataRefValue, data))
+	session.statement.AddClause(values)
 	var hasReturning bool
 	if session.statement.Dialector.WithReturning() {
 		if s, ok := session.statement.Clauses["RETURNING"].Expression.(clause.Select); !ok || len(s.Columns) == 0 {
-			session.statement.Dialector.SetQueryer(session.db)
-			pkColumnNames := session.statement.Dialector.PKColumnNames(session.statement.Tables[0].Name)
-			if pkColumnNames != nil && len(pkColumnNames) == 1 {
-				session.statement.AddClause(clause.Returning{Columns: []clause.Column{{Name: pkColumnNames[0]}}})
-				hasReturning = true
-			} else {
-				hasReturning = false
+			// dry run 不查询主键，避免访问数据库
+			if session.dryRun == nil {
+				pkColumnNames := session.statement.Dialector.PKColumnNames(session.db, session.statement.Tables[0].Name)
+				if pkColumnNames != nil && len(pkColumnNames) == 1 {
+					session.statement.AddClause(clause.Returning{Columns: []clause.Column{{Name: pkColumnNames[0]}}})
+					hasReturning = true
+				} else {
+					hasReturning = false
+				}
 			}
 		} else {
 			hasReturning = true
 		}
 	}
 
-	session.statement.Build("INSERT", "VALUES", "ON_CONFLICT", "RETURNING")
+	session.statement.Build("INSERT", "VALUES", "ON CONFLICT", "RETURNING")
 
 	if hasReturning {
-		rows, err := session.db.QueryContext(session.ctx, session.statement.SQL.String(), session.statement.SQLVars...)
+		rows, err := session.queryContext(session.statement.SQL.String(), session.statement.SQLVars...)
 		if err != nil {
 			return &ExecResult{err: err}
 		}
@@ -453,18 +742,13 @@ func (session *Session) insert(isBulk bool, dataRefValue reflect.Value, data int
 		return &ExecResult{isId: true, idList: idList}
 	}
 
-	result, err := session.db.ExecContext(session.ctx, session.statement.SQL.String(), session.statement.SQLVars...)
+	result, err := session.execContext(session.statement.SQL.String(), session.statement.SQLVars...)
 	if err != nil {
 		return &ExecResult{err: err}
 	}
 	lastInsertId, err := result.LastInsertId()
 	if err == nil {
-		var dataLen int
-		if isBulk {
-			dataLen = dataRefValue.Len()
-		} else {
-			dataLen = 1
-		}
+		dataLen := len(values.Values)
 		var lastInsertIdList []int64
 		if session.statement.Dialector.LastInsertIDReversed() {
 			for i := dataLen - 1; i >= 0; i-- {
@@ -484,25 +768,41 @@ func (session *Session) insert(isBulk bool, dataRefValue reflect.Value, data int
 
 // 单一创建，返回表自增ID; 值可以map或struct
 func (session *Session) Create(data interface{}) (lastInsertId int64, err error) {
+	session = session.getInstance()
 	defer session.Clear()
 	direct := reflect.Indirect(reflect.ValueOf(data))
 	vt := direct.Kind()
 	if vt != reflect.Struct && vt != reflect.Map {
 		return 0, fmt.Errorf("create an object using the given value must `map` or `struct` structure, got %v", vt)
 	}
-	result := session.insert(false, direct, data)
-	if result.err != nil {
+
+	direct = addressable(direct)
+	err = session.withHooks(direct, beforeCreate, afterCreate, func(s *Session) error {
+		values := convertCreateValues(direct, data)
+		s.setCreateTimes(direct, &values)
+		result := s.insert(values)
+		if result.err != nil {
+			return result.err
+		}
+		if result.isId {
+			lastInsertId = result.idList[0]
+		}
+		return nil
+	})
+	if err != nil {
 		return 0, err
 	}
-	if result.isId {
-		return result.idList[0], nil
-	}
-	return 0, nil
+	return lastInsertId, nil
+}
 
+type BulkCreateOptions struct {
+	BatchSize int // 每条 INSERT 语句的最大行数，默认根据方言的占位符上限计算
 }
 
-// 批量创建，返回表自增ID列表; 值可以map或struct组成的slice或array
-func (session *Session) BulkCreate(data interface{}) (lastInsertIdList []int64, err error) {
+// 批量创建，返回表自增ID列表; 值可以map或struct组成的slice或array；
+// 数据超过单条语句的限制时自动分批插入，多个批次在同一个事务中执行
+func (session *Session) BulkCreate(data interface{}, opts ...BulkCreateOptions) (lastInsertIdList []int64, err error) {
+	session = session.getInstance()
 	defer session.Clear()
 	direct := reflect.Indirect(reflect.ValueOf(data))
 	vt := direct.Kind()
@@ -513,93 +813,325 @@ func (session *Session) BulkCreate(data interface{}) (lastInsertIdList []int64,
 		return lastInsertIdList, fmt.Errorf("bulk create object using the given value cannot empty")
 	}
 
-	result := session.insert(true, direct, data)
-	if result.err != nil {
-		return result.idList, err
+	direct = addressable(direct)
+	err = session.withHooks(direct, beforeCreate, afterCreate, func(s *Session) (err error) {
+		lastInsertIdList, err = s.bulkCreate(direct, data, opts)
+		return err
+	})
+	if err != nil && err != ErrDryRun {
+		return nil, err
 	}
-	if result.isId {
-		return result.idList, nil
+	return lastInsertIdList, err
+}
+
+func (session *Session) bulkCreate(direct reflect.Value, data interface{}, opts []BulkCreateOptions) (lastInsertIdList []int64, err error) {
+	values := convertCreateValues(direct, data)
+	session.setCreateTimes(direct, &values)
+	batches := session.bulkBatches(values, opts)
+	if len(batches) <= 1 {
+		result := session.insert(values)
+		return result.idList, result.err
 	}
-	return
+
+	create := func(db *SqlDB) error {
+		for _, rows := range batches {
+			batch := session.Clone()
+			batch.db = db
+			result := batch.insert(clause.Values{Columns: values.Columns, Values: rows})
+			if result.err != nil && result.err != ErrDryRun {
+				return result.err
+			}
+			lastInsertIdList = append(lastInsertIdList, result.idList...)
+		}
+		return nil
+	}
+
+	if session.db.tx != nil || session.dryRun != nil {
+		err = create(session.db)
+	} else {
+		err = session.db.TxContext(session.ctx, create)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lastInsertIdList, nil
+}
+
+// 将待插入的行分批: 每批行数不超过 bulkBatchSize，方言限制了数据包大小时按估算的字节数进一步拆分
+func (session *Session) bulkBatches(values clause.Values, opts []BulkCreateOptions) (batches [][][]interface{}) {
+	batchSize := session.bulkBatchSize(len(values.Columns), opts)
+	maxBytes := session.maxPacketSize()
+	start, size := 0, 0
+	for i, row := range values.Values {
+		rowSize := 0
+		if maxBytes > 0 {
+			rowSize = estimateRowSize(row)
+		}
+		if i > start && (i-start >= batchSize || maxBytes > 0 && size+rowSize > maxBytes) {
+			batches = append(batches, values.Values[start:i])
+			start, size = i, 0
+		}
+		size += rowSize
+	}
+	if start < len(values.Values) {
+		batches = append(batches, values.Values[start:])
+	}
+	return batches
+}
+
+// 每批插入的行数，未指定时按方言的占位符上限计算
+func (session *Session) bulkBatchSize(columns int, opts []BulkCreateOptions) int {
+	if len(opts) > 0 && opts[0].BatchSize > 0 {
+		return opts[0].BatchSize
+	}
+
+	limiter, ok := session.statement.Dialector.(dialects.PlaceholderLimiter)
+	if !ok || columns == 0 {
+		return math.MaxInt32
+	}
+	if size := limiter.MaxPlaceholders() / columns; size > 0 {
+		return size
+	}
+	return 1
+}
+
+// 方言查询数据库信息使用的连接，dry run 时不访问数据库
+func (session *Session) queryer() dialects.Queryer {
+	if session.dryRun != nil {
+		return nil
+	}
+	return session.db
+}
+
+// 每批插入数据的字节数上限，预留语句本身的空间；dry run 时不查询数据库，不限制
+func (session *Session) maxPacketSize() int {
+	limiter, ok := session.statement.Dialector.(dialects.PacketLimiter)
+	if !ok || session.dryRun != nil {
+		return 0
+	}
+	if size := limiter.MaxPacketSize(session.db); size > 0 {
+		if size -= 4096; size > 0 {
+			return size
+		}
+		return 1
+	}
+	return 0
+}
+
+// 按转义后的文本保守估算一行数据在语句中占用的字节数
+func estimateRowSize(row []interface{}) (size int) {
+	for _, value := range row {
+		if valuer, ok := value.(driver.Valuer); ok {
+			if v, err := valuer.Value(); err == nil {
+				value = v
+			}
+		}
+		switch v := value.(type) {
+		case string:
+			size += 2*len(v) + 3
+		case []byte:
+			size += 2*len(v) + 3
+		default:
+			size += 32
+		}
+	}
+	return size
 }
 
 // 修改单一字段，返回受影响的行数
 func (session *Session) Update(column string, value interface{}) (affected int64, err error) {
+	session = session.getInstance()
 	defer session.Clear()
 	return session.BulkUpdate(map[string]interface{}{column: value})
 }
 
-// 批量修改多个字段，返回受影响的行数
+// 批量修改多个字段，返回受影响的行数；通过 Join 关联其他表时按方言生成多表修改语句
 func (session *Session) BulkUpdate(data map[string]interface{}) (affected int64, err error) {
+	session = session.getInstance()
+	defer session.Clear()
+	err = session.withHooks(session.hookModel(), beforeUpdate, afterUpdate, func(s *Session) (err error) {
+		affected, err = s.versionedUpdate(data)
+		return err
+	})
+	return affected, err
+}
+
+func (session *Session) update(data map[string]interface{}) (affected int64, err error) {
 	defer session.Clear()
 	if session.statement.SQL.String() == "" {
 		if _, ok := session.statement.Clauses["WHERE"]; !ok {
 			return 0, ErrMissingWhereClause
 		}
+		if session.Error != nil {
+			return 0, session.Error
+		}
+		data = session.setUpdateTimes(data)
+		if session.returning != nil && !session.nativeReturning() {
+			return session.emulateReturning(func(s *Session) (int64, error) {
+				return s.update(data)
+			}, false)
+		}
+		if session.applyDefaultScopes(); session.Error != nil {
+			return 0, session.Error
+		}
 		session.statement.SQL.Grow(180)
-		session.statement.AddClauseIfNotExists(clause.Update{Table: session.statement.Tables[0]})
-		session.statement.AddClause(clause.Assignments(data))
-		session.statement.Build("UPDATE", "SET", "WHERE")
+		if session.hasJoins() {
+			if err := session.buildJoinUpdate(data); err != nil {
+				return 0, err
+			}
+		} else {
+			session.statement.AddClauseIfNotExists(clause.Update{Table: session.statement.Tables[0]})
+			session.statement.AddClause(clause.Assignments(data))
+			session.statement.Build("UPDATE", "SET", "WHERE", "RETURNING")
+		}
 	}
 
-	result, err := session.db.ExecContext(session.ctx, session.statement.SQL.String(), session.statement.SQLVars...)
-	if err != nil {
-		return
-	}
-	return result.RowsAffected()
+	return session.execModify()
 }
 
-// 删除，必须要where条件，返回受影响的行数
+// 删除，必须要where条件，返回受影响的行数；通过 Join 关联其他表时只删除当前表的数据；
+// 软删除的表只设置删除时间，见 RegisterSoftDelete
 func (session *Session) Delete() (affected int64, err error) {
+	session = session.getInstance()
+	return session.hookedDelete(false)
+}
+
+func (session *Session) hookedDelete(force bool) (affected int64, err error) {
+	defer session.Clear()
+	err = session.withHooks(session.hookModel(), beforeDelete, afterDelete, func(s *Session) (err error) {
+		affected, err = s.delete(force)
+		return err
+	})
+	return affected, err
+}
+
+func (session *Session) delete(force bool) (affected int64, err error) {
 	defer session.Clear()
 	if session.statement.SQL.String() == "" {
 		if _, ok := session.statement.Clauses["WHERE"]; !ok {
 			return 0, ErrMissingWhereClause
 		}
+		if session.Error != nil {
+			return 0, session.Error
+		}
+		if column, ok := session.softDeleteColumn(); ok && !force && !session.unscoped {
+			return session.softDelete(column)
+		}
+		if session.returning != nil && !session.nativeReturning() {
+			return session.emulateReturning(func(s *Session) (int64, error) {
+				return s.delete(force)
+			}, true)
+		}
+		if session.applyDefaultScopes(); session.Error != nil {
+			return 0, session.Error
+		}
 		session.statement.SQL.Grow(100)
-		session.statement.AddClauseIfNotExists(clause.Delete{})
-		session.statement.AddClauseIfNotExists(clause.From{Tables: session.statement.Tables})
-		session.statement.Build("DELETE", "FROM", "WHERE")
-	}
-	result, err := session.db.ExecContext(session.ctx, session.statement.SQL.String(), session.statement.SQLVars...)
-	if err != nil {
-		return
+		if session.hasJoins() {
+			if err := session.buildJoinDelete(); err != nil {
+				return 0, err
+			}
+		} else {
+			session.statement.AddClauseIfNotExists(clause.Delete{})
+			session.statement.AddClauseIfNotExists(clause.From{Tables: session.statement.Tables})
+			session.statement.Build("DELETE", "FROM", "WHERE", "RETURNING")
+		}
 	}
-	return result.RowsAffected()
+
+	return session.execModify()
 }
 
 func (session *Session) Query() (*sqlx.Rows, error) {
+	session = session.getInstance()
 	defer session.Clear()
 	if session.Error != nil {
 		return nil, session.Error
 	}
 
 	if session.statement.SQL.String() == "" {
-		session.buildQuerySQL()
+		if err := session.buildQuerySQL(); err != nil {
+			return nil, err
+		}
 	}
 
-	return session.db.QueryContext(session.ctx, session.statement.SQL.String(), session.statement.SQLVars...)
+	return session.queryContext(session.statement.SQL.String(), session.statement.SQLVars...)
 }
 
 func (session *Session) QueryRow() *sqlx.Row {
+	session = session.getInstance()
 	defer session.Clear()
+	if session.Error != nil {
+		return errRow(session.Error)
+	}
 	if session.statement.SQL.String() == "" {
-		session.buildQuerySQL()
+		if err := session.buildQuerySQL(); err != nil {
+			return errRow(err)
+		}
+	}
+
+	if session.dryRun != nil {
+		return errRow(session.recordSQL(session.statement.SQL.String(), session.statement.SQLVars))
 	}
 
 	return session.db.QueryRowContext(session.ctx, session.statement.SQL.String(), session.statement.SQLVars...)
 }
 
 func (session *Session) Hint(query string) *Session {
+	session = session.getInstance()
 	session.statement.Hint = query
 	return session
 }
 
 func (session *Session) Master() *Session {
-	session.db.isMaster = true
+	session = session.getInstance()
+	session.db = session.db.master()
 	return session
 }
 
 func (session *Session) Clear() {
 	session.statement.ReInit()
+	session.cursor = nil
+	session.unscoped = false
+	session.returning = nil
+	session.trashed = withoutTrashed
+	session.model = nil
+	session.skipHooks = false
+	session.preloads = nil
+}
+
+// 复制会话，复制后的会话与原会话的查询条件互不影响，可在不同 goroutine 中并发使用
+func (session *Session) Clone() *Session {
+	return &Session{
+		Error:     session.Error,
+		db:        session.db,
+		statement: session.statement.Clone(),
+		ctx:       session.ctx,
+		cursor:    session.cursor,
+		immutable: session.immutable,
+		unscoped:  session.unscoped,
+		dryRun:    session.dryRun,
+		returning: session.returning,
+		trashed:   session.trashed,
+		model:     session.model,
+		skipHooks: session.skipHooks,
+		preloads:  session.preloads,
+	}
+}
+
+// 开启写时复制模式，之后的每个链式方法及执行方法都作用在新的会话上，原会话保持不变，
+// 可用于定义基础查询后派生 Count、Find、Paginate 等多个查询，如:
+//
+//	active := db.Table("auth_user").Where("is_active = ?", true).Immutable()
+//	total, _ := active.Count()
+//	err := active.Limit(10).Find(&users)
+func (session *Session) Immutable() *Session {
+	newSession := session.Clone()
+	newSession.immutable = true
+	return newSession
+}
+
+func (session *Session) getInstance() *Session {
+	if session.immutable {
+		return session.Clone()
+	}
+	return session
 }
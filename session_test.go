@@ -4,10 +4,13 @@ import (
 	"database/sql"
 	"errors"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/clause"
 	"github.com/binwen/sqldb/tests"
 )
 
@@ -164,6 +167,58 @@ func TestGroupBy(t *testing.T) {
 	})
 }
 
+func TestGroupAggregate(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithName("user1", "user1", "user1", "user2", "user2", "user3")
+		if _, err := tests.DBEngine.Table("auth_user").Where("id = ?", 1).Update("age", 20); err != nil {
+			t.Fatal(err)
+		}
+
+		var counts map[string]int64
+		if err := tests.DBEngine.Table("auth_user").GroupBy("username").GroupAggregate("count(*)", &counts); err != nil {
+			t.Error(err)
+		} else if len(counts) != 3 || counts["user1"] != 3 || counts["user2"] != 2 {
+			t.Errorf("group counts should be `map[user1:3 user2:2 user3:1]`, got `%v`", counts)
+		}
+
+		var sums = map[string]float64{}
+		if err := tests.DBEngine.Table("auth_user").GroupBy("username").Having("count(*) > ?", 1).GroupAggregate("sum(age)", &sums); err != nil {
+			t.Error(err)
+		} else if len(sums) != 2 || sums["user1"] != 56 {
+			t.Errorf("group sums should be `map[user1:56 user2:36]`, got `%v`", sums)
+		}
+
+		var results []sqldb.GroupResult
+		if err := tests.DBEngine.Table("auth_user").GroupBy("username", "age").Asc("username", "age").GroupAggregate("count(*)", &results); err != nil {
+			t.Error(err)
+		} else if len(results) != 4 {
+			t.Errorf("group results count should be `4`, got `%v`", len(results))
+		} else if results[0].Keys[0] != "user1" || results[0].Keys[1] != int64(18) || results[0].Value != int64(2) {
+			t.Errorf("first group result should be `{[user1 18] 2}`, got `%v`", results[0])
+		}
+
+		if err := tests.DBEngine.Table("auth_user").GroupBy("username, age").GroupAggregate("count(*)", &counts); err == nil {
+			t.Error("group aggregate into map with composite keys should return error")
+		}
+
+		if err := tests.DBEngine.Table("auth_user").GroupAggregate("count(*)", &counts); err == nil {
+			t.Error("group aggregate without GROUP BY should return error")
+		}
+
+		var ageCounts map[int64]int64
+		if err := tests.DBEngine.Table("auth_user").GroupBy("COALESCE(age, 0)").GroupAggregate("count(*)", &ageCounts); err != nil {
+			t.Error(err)
+		} else if len(ageCounts) != 2 || ageCounts[18] != 5 || ageCounts[20] != 1 {
+			t.Errorf("group by expression with comma should be kept whole, got `%v`", ageCounts)
+		}
+
+		var total float64
+		if _, err := tests.DBEngine.Table("auth_user").GroupBy("username").Sum("age", &total); err == nil {
+			t.Error("sum with GROUP BY should return error instead of the first group")
+		}
+	})
+}
+
 func TestJoin(t *testing.T) {
 	tests.RunWithDB(t, func(t *testing.T) {
 		tests.InsertAuthUserWithId(1, 2, 3, 4)
@@ -505,6 +560,96 @@ func TestCount(t *testing.T) {
 	})
 }
 
+func TestAggregate(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3, 4)
+		if _, err := tests.DBEngine.Table("auth_user").Where("id in ?", []int{1, 2}).Update("age", 20); err != nil {
+			t.Fatal(err)
+		}
+
+		var sum float64
+		if ok, err := tests.DBEngine.Table("auth_user").Sum("age", &sum); err != nil {
+			t.Error(err)
+		} else if !ok || sum != 76 {
+			t.Errorf("sum value should `76`, got `%v` `%v`", sum, ok)
+		}
+
+		var avg sql.NullFloat64
+		if ok, err := tests.DBEngine.Table("auth_user").Where("id in ?", []int{1, 3}).Avg("age", &avg); err != nil {
+			t.Error(err)
+		} else if !ok || !avg.Valid || avg.Float64 != 19 {
+			t.Errorf("avg value should `19`, got `%v` `%v`", avg, ok)
+		}
+
+		var min int
+		if ok, err := tests.DBEngine.Table("auth_user").Min("age", &min); err != nil {
+			t.Error(err)
+		} else if !ok || min != 18 {
+			t.Errorf("min value should `18`, got `%v` `%v`", min, ok)
+		}
+
+		var maxName string
+		if ok, err := tests.DBEngine.Table("auth_user").Max("username", &maxName); err != nil {
+			t.Error(err)
+		} else if !ok || maxName != "user4" {
+			t.Errorf("max username should `user4`, got `%v` `%v`", maxName, ok)
+		}
+
+		var firstJoined interface{}
+		if ok, err := tests.DBEngine.Table("auth_user").Min("date_joined", &firstJoined); err != nil {
+			t.Error(err)
+		} else if !ok || firstJoined == nil {
+			t.Errorf("min date should not be NULL, got `%v` `%v`", firstJoined, ok)
+		}
+		if tests.DBEngine.DriverName() != "sqlite3" {
+			var joined time.Time
+			if ok, err := tests.DBEngine.Table("auth_user").Min("date_joined", &joined); err != nil || !ok || joined.IsZero() {
+				t.Errorf("min date should be scanned into time, got `%v` `%v` `%v`", joined, ok, err)
+			}
+		}
+
+		max := -1
+		if ok, err := tests.DBEngine.Table("auth_user as u").Join(
+			"join auth_user_groups as ug on ug.user_id = u.id",
+		).Max("u.age", &max); err != nil {
+			t.Error(err)
+		} else if ok || max != -1 {
+			t.Errorf("max value of empty set should be NULL and keep dest, got `%v` `%v`", max, ok)
+		}
+
+		var emptySum sql.NullInt64
+		if ok, err := tests.DBEngine.Table("auth_user").Where("id > ?", 10).Sum("age", &emptySum); err != nil {
+			t.Error(err)
+		} else if ok || emptySum.Valid {
+			t.Errorf("sum value of empty set should be NULL, got `%v` `%v`", emptySum, ok)
+		}
+	})
+}
+
+func TestPluck(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3)
+		var names []string
+		if err := tests.DBEngine.Table("auth_user").Select("id, age").Where("id > ?", 1).Pluck("username", &names); err != nil {
+			t.Error(err)
+		} else if len(names) != 2 || names[0] != "user2" {
+			t.Errorf("pluck names should `[user2 user3]`, got `%v`", names)
+		}
+
+		var ids []int64
+		if err := tests.DBEngine.Table("auth_user").Desc("id").Pluck("id", &ids); err != nil {
+			t.Error(err)
+		} else if len(ids) != 3 || ids[0] != 3 {
+			t.Errorf("pluck ids should `[3 2 1]`, got `%v`", ids)
+		}
+
+		var id int64
+		if err := tests.DBEngine.Table("auth_user").Pluck("id", &id); err == nil {
+			t.Error("pluck into non-slice destination should return error")
+		}
+	})
+}
+
 func TestCreatByMap(t *testing.T) {
 	tests.RunWithDB(t, func(t *testing.T) {
 		if lastId, err := tests.DBEngine.Table("auth_user").Create(map[string]interface{}{
@@ -622,6 +767,58 @@ func TestCreateByStruct(t *testing.T) {
 	})
 }
 
+func TestCreateOnConflict(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthGroup(1)
+		upsert := clause.OnConflict{
+			Columns:   []clause.Column{{Name: "id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"name": "upserted"}),
+		}
+
+		for _, op := range []func(s *sqldb.Session) error{
+			func(s *sqldb.Session) error {
+				_, err := s.AddClause(upsert).Create(map[string]interface{}{"id": 1, "name": "group1"})
+				return err
+			},
+			func(s *sqldb.Session) error {
+				_, err := s.AddClause(upsert).InsertFromSelect([]string{"id", "name"}, tests.DBEngine.Table("auth_group").Select("id", "name"))
+				return err
+			},
+		} {
+			statements, err := tests.DBEngine.Table("auth_group").ToSQL(op)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(statements) != 1 || !strings.Contains(statements[0].SQL, "ON CONFLICT") {
+				t.Errorf("insert should build on conflict clause, got `%v`", statements)
+			}
+		}
+
+		if tests.DBEngine.DriverName() == "mysql" {
+			return
+		}
+		if _, err := tests.DBEngine.Table("auth_group").AddClause(upsert).Create(map[string]interface{}{"id": 1, "name": "group1"}); err != nil {
+			t.Fatal(err)
+		}
+		var name string
+		if err := tests.DBEngine.Table("auth_group").Select("name").Where("id = ?", 1).First(&name); err != nil {
+			t.Fatal(err)
+		} else if name != "upserted" {
+			t.Errorf("conflicting create should update the row, got `%v`", name)
+		}
+
+		tests.InsertAuthGroup(2)
+		if _, err := tests.DBEngine.Table("auth_group").AddClause(upsert).InsertFromSelect([]string{"id", "name"}, tests.DBEngine.Table("auth_group").Select("id", "name")); err != nil {
+			t.Fatal(err)
+		}
+		if count, err := tests.DBEngine.Table("auth_group").Where("name = ?", "upserted").Count(); err != nil {
+			t.Fatal(err)
+		} else if count != 2 {
+			t.Errorf("conflicting insert from select should update every row, got %d", count)
+		}
+	})
+}
+
 func TestBulkCreateByMapSlice(t *testing.T) {
 	tests.RunWithDB(t, func(t *testing.T) {
 		if lastIdList, err := tests.DBEngine.Table("auth_user").BulkCreate([]map[string]interface{}{
@@ -899,6 +1096,57 @@ func TestBulkCreateByStructSlice(t *testing.T) {
 	})
 }
 
+func TestBulkCreateInBatches(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		users := make([]tests.AuthUser, 1000)
+		for i := range users {
+			users[i] = tests.AuthUser{UserName: "user" + strconv.Itoa(i), Age: i, ModelTime: tests.ModelTime{DateJoined: time.Now()}}
+		}
+
+		lastIdList, err := tests.DBEngine.Table("auth_user").BulkCreate(users)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int64
+		if err := tests.DBEngine.Table("auth_user").Select("id").Asc("age").Find(&ids); err != nil {
+			t.Fatal(err)
+		}
+		if len(lastIdList) != len(users) || len(ids) != len(users) {
+			t.Fatalf("should create `%v` users, got `%v` ids and `%v` rows", len(users), len(lastIdList), len(ids))
+		}
+		for i := range ids {
+			if lastIdList[i] != ids[i] {
+				t.Fatalf("id list should keep data order, index `%v` should be `%v`, got `%v`", i, ids[i], lastIdList[i])
+			}
+		}
+		if count, err := tests.DBEngine.Table("auth_user").Where("age = ?", 0).Count(); err != nil || count != 1 {
+			t.Errorf("zero age should be inserted as `0`, got `%v` `%v`", count, err)
+		}
+
+		statements, err := tests.DBEngine.Table("auth_user").ToSQL(func(s *sqldb.Session) error {
+			_, err := s.BulkCreate(users[:7], sqldb.BulkCreateOptions{BatchSize: 3})
+			return err
+		})
+		if err != nil {
+			t.Error(err)
+		} else if len(statements) != 3 || len(statements[2].Vars) != 5 {
+			t.Errorf("7 rows should be split into `3` statements, got `%v`", statements)
+		}
+
+		rows := make([]map[string]interface{}, 5)
+		for i := range rows {
+			rows[i] = map[string]interface{}{"username": "batch", "is_superuser": false, "age": i, "date_joined": time.Now()}
+		}
+		rows[4]["age"] = nil
+		if _, err := tests.DBEngine.Table("auth_user").BulkCreate(rows, sqldb.BulkCreateOptions{BatchSize: 2}); err == nil {
+			t.Error("bulk create with invalid row should return error")
+		}
+		if count, _ := tests.DBEngine.Table("auth_user").Where("username = ?", "batch").Count(); count != 0 {
+			t.Errorf("all batches should be rolled back, got `%v` rows", count)
+		}
+	})
+}
+
 func TestDelete(t *testing.T) {
 	tests.RunWithDB(t, func(t *testing.T) {
 		tests.InsertAuthUserWithId(1, 2, 3, 4, 5, 6, 8)
@@ -1432,3 +1680,64 @@ func TestExpr(t *testing.T) {
 		}
 	})
 }
+
+func TestClone(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3, 4, 5)
+		base := tests.DBEngine.Table("auth_user").Where("id > ?", 1)
+		query := base.Clone().Where("id < ?", 4)
+
+		if count, err := query.Count(); err != nil {
+			t.Error(err)
+		} else if count != 2 {
+			t.Errorf("cloned query count should be `2`, got `%v`", count)
+		}
+
+		if count, err := base.Count(); err != nil {
+			t.Error(err)
+		} else if count != 4 {
+			t.Errorf("base query count should be `4`, got `%v`", count)
+		}
+	})
+}
+
+func TestImmutable(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3, 4, 5, 6)
+		active := tests.DBEngine.Table("auth_user").Where("id > ?", 2).Immutable()
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				var users []tests.SimpleAuthUser
+				if err := active.Where("id <> ?", 3).Asc("id").Limit(2).Find(&users); err != nil {
+					t.Error(err)
+				} else if len(users) != 2 || users[0].Id != 4 {
+					t.Errorf("derived query should find users `[4 5]`, got `%v`", users)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		if count, err := active.Count(); err != nil {
+			t.Error(err)
+		} else if count != 4 {
+			t.Errorf("base query count should be `4`, got `%v`", count)
+		}
+
+		var users []tests.SimpleAuthUser
+		if page, err := active.Desc("id").Paginate(2, 3, &users); err != nil {
+			t.Error(err)
+		} else if page.Total != 4 || len(users) != 1 || users[0].Id != 3 {
+			t.Errorf("paginate from base query should find user `3` of total `4`, got `%v` of total `%v`", users, page.Total)
+		}
+
+		if count, err := active.Count(); err != nil {
+			t.Error(err)
+		} else if count != 4 {
+			t.Errorf("base query count should still be `4`, got `%v`", count)
+		}
+	})
+}
@@ -0,0 +1,137 @@
+package sqldb
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/binwen/sqldb/clause"
+)
+
+// 软删除表的默认字段
+const DefaultSoftDeleteColumn = "deleted_at"
+
+type trashedMode int
+
+const (
+	withoutTrashed trashedMode = iota // 只包含未删除的行，默认
+	withTrashed                       // 包含已软删除的行
+	onlyTrashed                       // 只包含已软删除的行
+)
+
+var (
+	softDeleteMu      sync.RWMutex
+	softDeleteMapping = map[string]string{}
+)
+
+// 注册表(或表别名)为软删除模式，column 为记录删除时间的字段，默认为 deleted_at；
+// 注册后 Delete 只设置删除时间，查询、修改、删除时自动过滤已删除的行，可通过 Unscoped 忽略
+func RegisterSoftDelete(table string, column ...string) {
+	softDeleteMu.Lock()
+	defer softDeleteMu.Unlock()
+	softDeleteMapping[table] = DefaultSoftDeleteColumn
+	if len(column) > 0 && column[0] != "" {
+		softDeleteMapping[table] = column[0]
+	}
+}
+
+// 取消表(或表别名)的软删除模式
+func UnregisterSoftDelete(table string) {
+	softDeleteMu.Lock()
+	defer softDeleteMu.Unlock()
+	delete(softDeleteMapping, table)
+}
+
+// 当前表的软删除字段
+func (session *Session) softDeleteColumn() (clause.Column, bool) {
+	if len(session.statement.Tables) == 0 {
+		return clause.Column{}, false
+	}
+
+	table := session.statement.Tables[0]
+	softDeleteMu.RLock()
+	column, ok := softDeleteMapping[table.Name]
+	if !ok && table.Alias != "" {
+		column, ok = softDeleteMapping[table.Alias]
+	}
+	softDeleteMu.RUnlock()
+	if !ok {
+		return clause.Column{}, false
+	}
+	if table.Alias != "" {
+		return clause.Column{Table: table.Alias, Name: column}, true
+	}
+	return clause.Column{Table: table.Name, Name: column}, true
+}
+
+// 按 WithTrashed、OnlyTrashed 过滤已软删除的行，随默认作用域应用
+func (session *Session) applySoftDelete() {
+	column, ok := session.softDeleteColumn()
+	if !ok {
+		return
+	}
+
+	// 已有的 OR 条件需先加括号，否则软删除条件只约束最后一个 OR 分支
+	if session.trashed != withTrashed {
+		session.statement.groupWhere()
+	}
+	switch session.trashed {
+	case withoutTrashed:
+		session.statement.AddClause(clause.Where{Exprs: []clause.Expression{clause.EQ{Column: column}}})
+	case onlyTrashed:
+		session.statement.AddClause(clause.Where{Exprs: []clause.Expression{clause.NEQ{Column: column}}})
+	}
+}
+
+// 查询、修改、删除时包含已软删除的行
+func (session *Session) WithTrashed() *Session {
+	session = session.getInstance()
+	session.trashed = withTrashed
+	return session
+}
+
+// 查询、修改、删除时只包含已软删除的行
+func (session *Session) OnlyTrashed() *Session {
+	session = session.getInstance()
+	session.trashed = onlyTrashed
+	return session
+}
+
+// 恢复已软删除的行，返回受影响的行数
+func (session *Session) Restore() (affected int64, err error) {
+	session = session.getInstance()
+	column, ok := session.softDeleteColumn()
+	if !ok {
+		err = errors.New("missing table while restoring")
+		if len(session.statement.Tables) > 0 {
+			err = fmt.Errorf("table `%s` is not registered for soft delete", session.statement.Tables[0].Name)
+		}
+		session.Clear()
+		return 0, err
+	}
+
+	session.trashed = onlyTrashed
+	return session.BulkUpdate(map[string]interface{}{session.softDeleteAssignment(column): clause.Expr{SQL: "NULL"}})
+}
+
+// 物理删除，包含已软删除的行，返回受影响的行数
+func (session *Session) ForceDelete() (affected int64, err error) {
+	session = session.getInstance()
+	if session.trashed == withoutTrashed {
+		session.trashed = withTrashed
+	}
+	return session.hookedDelete(true)
+}
+
+// 软删除，设置删除时间；已在删除钩子中执行，不调用修改钩子，也不使用乐观锁
+func (session *Session) softDelete(column clause.Column) (affected int64, err error) {
+	return session.update(map[string]interface{}{session.softDeleteAssignment(column): session.db.now()})
+}
+
+// 修改语句中的软删除字段，多表修改时带上表名
+func (session *Session) softDeleteAssignment(column clause.Column) string {
+	if session.hasJoins() {
+		return column.Table + "." + column.Name
+	}
+	return column.Name
+}
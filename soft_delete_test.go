@@ -0,0 +1,130 @@
+package sqldb_test
+
+import (
+	"testing"
+
+	"github.com/binwen/sqldb"
+	"github.com/binwen/sqldb/tests"
+)
+
+func TestSoftDelete(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithName("user1", "user2", "user3", "user4")
+		defer sqldb.UnregisterSoftDelete("trashable_user")
+		sqldb.RegisterSoftDelete("trashable_user", "last_login")
+		table := "auth_user as trashable_user"
+
+		if affected, err := tests.DBEngine.Table(table).Where("username in ?", []string{"user1", "user2"}).Delete(); err != nil {
+			t.Fatal(err)
+		} else if affected != 2 {
+			t.Errorf("soft deleted rows should be `2`, got `%v`", affected)
+		}
+		if affected, err := tests.DBEngine.Table(table).Where("username = ?", "user1").Delete(); err != nil || affected != 0 {
+			t.Errorf("deleted row should not be deleted again, got `%v` `%v`", affected, err)
+		}
+
+		if count, err := tests.DBEngine.Table("auth_user").Count(); err != nil || count != 4 {
+			t.Errorf("soft delete should keep rows, got `%v` `%v`", count, err)
+		}
+		var names []string
+		if err := tests.DBEngine.Table(table).Asc("id").Pluck("username", &names); err != nil {
+			t.Error(err)
+		} else if len(names) != 2 || names[0] != "user3" {
+			t.Errorf("deleted rows should be filtered, got `%v`", names)
+		}
+		if count, err := tests.DBEngine.Table(table).WithTrashed().Count(); err != nil || count != 4 {
+			t.Errorf("with trashed count should be `4`, got `%v` `%v`", count, err)
+		}
+		if count, err := tests.DBEngine.Table(table).Unscoped().Count(); err != nil || count != 4 {
+			t.Errorf("unscoped count should be `4`, got `%v` `%v`", count, err)
+		}
+		names = nil
+		if err := tests.DBEngine.Table(table).OnlyTrashed().Asc("id").Pluck("username", &names); err != nil {
+			t.Error(err)
+		} else if len(names) != 2 || names[0] != "user1" || names[1] != "user2" {
+			t.Errorf("only trashed should return deleted rows, got `%v`", names)
+		}
+		if affected, err := tests.DBEngine.Table(table).Where("username like ?", "user%").Update("age", 30); err != nil || affected != 2 {
+			t.Errorf("update should skip deleted rows, got `%v` `%v`", affected, err)
+		}
+
+		if affected, err := tests.DBEngine.Table(table).Where("username = ?", "user1").Restore(); err != nil || affected != 1 {
+			t.Errorf("restored rows should be `1`, got `%v` `%v`", affected, err)
+		}
+		if count, err := tests.DBEngine.Table(table).Count(); err != nil || count != 3 {
+			t.Errorf("restored row should be visible, got `%v` `%v`", count, err)
+		}
+
+		if affected, err := tests.DBEngine.Table(table).Where("username in ?", []string{"user2", "user3"}).ForceDelete(); err != nil || affected != 2 {
+			t.Errorf("force delete should remove trashed and live rows, got `%v` `%v`", affected, err)
+		}
+		if affected, err := tests.DBEngine.Table(table).Unscoped().Where("username = ?", "user4").Delete(); err != nil || affected != 1 {
+			t.Errorf("unscoped delete should remove row, got `%v` `%v`", affected, err)
+		}
+		if count, err := tests.DBEngine.Table("auth_user").Count(); err != nil || count != 1 {
+			t.Errorf("only restored row should remain, got `%v` `%v`", count, err)
+		}
+
+		if _, err := tests.DBEngine.Table("auth_user").Where("id > ?", 0).Restore(); err == nil {
+			t.Error("restore should fail for table without soft delete")
+		}
+		if _, err := tests.DBEngine.Table(table).Restore(); err != sqldb.ErrMissingWhereClause {
+			t.Errorf("restore without where should fail, got `%v`", err)
+		}
+
+		sqldb.UnregisterSoftDelete("trashable_user")
+		if affected, err := tests.DBEngine.Table(table).Where("id > ?", 0).Delete(); err != nil || affected != 1 {
+			t.Errorf("unregistered table should be deleted physically, got `%v` `%v`", affected, err)
+		}
+	})
+}
+
+func TestSoftDeleteWithOr(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithName("user1", "user2", "user3")
+		defer sqldb.UnregisterSoftDelete("trashable_user")
+		sqldb.RegisterSoftDelete("trashable_user", "last_login")
+		table := "auth_user as trashable_user"
+
+		if _, err := tests.DBEngine.Table(table).Where("username = ?", "user1").Delete(); err != nil {
+			t.Fatal(err)
+		}
+		if count, err := tests.DBEngine.Table(table).Where("username = ?", "user1").Or("username = ?", "user2").Count(); err != nil || count != 1 {
+			t.Errorf("deleted row should be filtered from every OR branch, got `%v` `%v`", count, err)
+		}
+		if affected, err := tests.DBEngine.Table(table).Where("username = ?", "user1").Or("username = ?", "user2").Delete(); err != nil || affected != 1 {
+			t.Errorf("deleted row should not be deleted again, got `%v` `%v`", affected, err)
+		}
+		if count, err := tests.DBEngine.Table(table).OnlyTrashed().Where("username = ?", "user1").Or("username = ?", "user3").Count(); err != nil || count != 1 {
+			t.Errorf("only trashed should apply to every OR branch, got `%v` `%v`", count, err)
+		}
+
+		session := tests.DBEngine.Table(table)
+		if _, err := session.Count(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := session.Restore(); err == nil {
+			t.Error("restore without table should return error")
+		}
+	})
+}
+
+func TestSoftDeleteToSQL(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		defer sqldb.UnregisterSoftDelete("trashable_user")
+		sqldb.RegisterSoftDelete("trashable_user", "last_login")
+		statements, err := tests.DBEngine.Table("auth_user as trashable_user").ToSQL(func(session *sqldb.Session) error {
+			_, err := session.Where("id = ?", 1).Delete()
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(statements) != 1 {
+			t.Fatalf("soft delete should record one statement, got `%v`", statements)
+		}
+		if tests.DBEngine.DriverName() == "sqlite3" && statements[0].SQL != "UPDATE `auth_user` AS `trashable_user` SET `last_login`=? WHERE id = ? AND `trashable_user`.`last_login` IS NULL" {
+			t.Errorf("soft delete should build update statement, got `%v`", statements[0].SQL)
+		}
+	})
+}
@@ -53,18 +53,27 @@ func (db *SqlDB) Rebind(query string) string {
 	return db.engine.Slave().Rebind(query)
 }
 
-func (db *SqlDB) getDB() ISqlx {
+// 事务中使用事务连接，写操作(master 为 true)及主库 SqlDB 使用主库，其余使用从库
+func (db *SqlDB) getDB(master bool) ISqlx {
 	if db.tx != nil {
 		return db.tx.Unsafe()
 	}
 
-	if db.isMaster {
+	if master || db.isMaster {
 		return db.engine.Master().Unsafe()
 	}
 
 	return db.engine.Slave().Unsafe()
 }
 
+// 返回读操作也路由到主库的 SqlDB；SqlDB 会被多个会话、goroutine 共享，因此返回副本而不修改 db
+func (db *SqlDB) master() *SqlDB {
+	if db.isMaster {
+		return db
+	}
+	return &SqlDB{engine: db.engine, tx: db.tx, isMaster: true, logging: db.logging}
+}
+
 func (db *SqlDB) Exec(query string, args ...interface{}) (result sql.Result, err error) {
 	defer func(start time.Time) {
 		logger.ExplainSQL(&logger.QueryStatus{
@@ -77,10 +86,11 @@ func (db *SqlDB) Exec(query string, args ...interface{}) (result sql.Result, err
 
 	}(time.Now())
 
-	db.isMaster = true
-
-	query, newArgs := db.convert(query, args)
-	return db.getDB().Exec(query, newArgs...)
+	query, newArgs, err := db.convert(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return db.getDB(true).Exec(query, newArgs...)
 }
 
 func (db *SqlDB) ExecContext(ctx context.Context, query string, args ...interface{}) (result sql.Result, err error) {
@@ -95,10 +105,11 @@ func (db *SqlDB) ExecContext(ctx context.Context, query string, args ...interfac
 
 	}(time.Now())
 
-	db.isMaster = true
-
-	query, newArgs := db.convert(query, args)
-	return db.getDB().ExecContext(ctx, query, newArgs...)
+	query, newArgs, err := db.convert(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return db.getDB(true).ExecContext(ctx, query, newArgs...)
 }
 
 func (db *SqlDB) Query(query string, args ...interface{}) (rows *sqlx.Rows, err error) {
@@ -112,8 +123,11 @@ func (db *SqlDB) Query(query string, args ...interface{}) (rows *sqlx.Rows, err
 		}, db.logging)
 	}(time.Now())
 
-	query, newArgs := db.convert(query, args)
-	return db.getDB().Queryx(query, newArgs...)
+	query, newArgs, err := db.convert(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return db.getDB(false).Queryx(query, newArgs...)
 }
 
 func (db *SqlDB) QueryContext(ctx context.Context, query string, args ...interface{}) (rows *sqlx.Rows, err error) {
@@ -127,8 +141,11 @@ func (db *SqlDB) QueryContext(ctx context.Context, query string, args ...interfa
 		}, db.logging)
 	}(time.Now())
 
-	query, newArgs := db.convert(query, args)
-	return db.getDB().QueryxContext(ctx, query, newArgs...)
+	query, newArgs, err := db.convert(query, args)
+	if err != nil {
+		return nil, err
+	}
+	return db.getDB(false).QueryxContext(ctx, query, newArgs...)
 }
 
 func (db *SqlDB) QueryRow(query string, args ...interface{}) (row *sqlx.Row) {
@@ -142,9 +159,12 @@ func (db *SqlDB) QueryRow(query string, args ...interface{}) (row *sqlx.Row) {
 		}, db.logging)
 	}(time.Now())
 
-	query, newArgs := db.convert(query, args)
+	query, newArgs, err := db.convert(query, args)
+	if err != nil {
+		return errRow(err)
+	}
 
-	return db.getDB().QueryRowx(query, newArgs...)
+	return db.getDB(false).QueryRowx(query, newArgs...)
 }
 
 func (db *SqlDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) (row *sqlx.Row) {
@@ -158,17 +178,31 @@ func (db *SqlDB) QueryRowContext(ctx context.Context, query string, args ...inte
 		}, db.logging)
 	}(time.Now())
 
-	query, newArgs := db.convert(query, args)
+	query, newArgs, err := db.convert(query, args)
+	if err != nil {
+		return errRow(err)
+	}
 
-	return db.getDB().QueryRowxContext(ctx, query, newArgs...)
+	return db.getDB(false).QueryRowxContext(ctx, query, newArgs...)
 }
 
-func (db *SqlDB) convert(query string, args []interface{}) (string, []interface{}) {
+// 绑定命名参数或展开 IN 参数，命名参数未提供时返回错误
+func (db *SqlDB) convert(query string, args []interface{}) (string, []interface{}, error) {
 	var (
 		newQuery string
 		newArgs  []interface{}
 		err      error
 	)
+	if namedVars, ok := ConvertNamedVars(args); ok && strings.Contains(query, "@") {
+		expr := clause.NamedExpr{SQL: query, Vars: namedVars}
+		if err := expr.Check(); err != nil {
+			return query, args, err
+		}
+		stmt := &Statement{Dialector: db.engine.Dialector, Clauses: map[string]clause.Clause{}}
+		expr.Build(stmt)
+		return stmt.SQL.String(), stmt.SQLVars, nil
+	}
+
 	if !IsInsertSQL(query) {
 		newQuery, newArgs, err = sqlx.In(query, args...)
 	} else {
@@ -176,14 +210,14 @@ func (db *SqlDB) convert(query string, args []interface{}) (string, []interface{
 	}
 
 	if err != nil {
-		return query, args
+		return query, args, nil
 	}
 
 	if i := strings.Index(newQuery, "?"); i != -1 {
 		newQuery = db.Rebind(newQuery)
 	}
 
-	return newQuery, newArgs
+	return newQuery, newArgs, nil
 }
 
 func (db *SqlDB) Tx(fn func(db *SqlDB) error) (err error) {
@@ -263,6 +297,13 @@ func (db *SqlDB) DriverName() string {
 	return db.engine.Master().DriverName()
 }
 
+func (db *SqlDB) now() time.Time {
+	if db.engine.nowFunc != nil {
+		return db.engine.nowFunc()
+	}
+	return time.Now()
+}
+
 func NewSqlDB(engine *ConnectionEngine, logging bool) *SqlDB {
 	return &SqlDB{engine: engine, logging: logging}
 }
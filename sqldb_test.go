@@ -1,6 +1,7 @@
 package sqldb_test
 
 import (
+	"database/sql"
 	"errors"
 	"os"
 	"strconv"
@@ -241,6 +242,68 @@ func TestQueryIn(t *testing.T) {
 	})
 }
 
+func TestNamedArgs(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3)
+		if result, err := tests.DBEngine.Exec(
+			"update auth_user set age = @age where id in (@ids)",
+			sql.Named("age", 20), sql.Named("ids", []int{1, 2}),
+		); err != nil {
+			t.Error(err)
+		} else if affected, _ := result.RowsAffected(); affected != 2 {
+			t.Errorf("rows affected should be `2`, got `%v`", affected)
+		}
+
+		rows, err := tests.DBEngine.Query("select id from auth_user where age = @age", map[string]interface{}{"age": 20})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var count int
+		for rows.Next() {
+			count++
+		}
+		rows.Close()
+		if count != 2 {
+			t.Errorf("query with named map should find `2` rows, got `%v`", count)
+		}
+
+		filter := struct {
+			UserName string `db:"username"`
+			Age      int
+		}{UserName: "user3", Age: 18}
+		var user tests.AuthUser
+		if err := tests.DBEngine.Raw("select * from auth_user where username = @username and age = @age", filter).Fetch(&user); err != nil {
+			t.Error(err)
+		} else if user.Id != 3 {
+			t.Errorf("raw query with named struct should find user `3`, got `%v`", user.Id)
+		}
+
+		if count, err := tests.DBEngine.Table("auth_user").Where("age = @age", sql.Named("age", 20)).Where(
+			"id <> @id", map[string]interface{}{"id": 1},
+		).Count(); err != nil {
+			t.Error(err)
+		} else if count != 1 {
+			t.Errorf("session count with named args should be `1`, got `%v`", count)
+		}
+
+		if _, err := tests.DBEngine.Query("select id from auth_user where age = @age and id = @id", sql.Named("age", 20)); err == nil || err.Error() != "named argument @id not found" {
+			t.Errorf("missing named argument should return error, got `%v`", err)
+		}
+		if _, err := tests.DBEngine.Table("auth_user").Where("age = @age and id = @id", sql.Named("age", 20)).Count(); err == nil {
+			t.Error("session with missing named argument should return error")
+		}
+
+		for _, arg := range []interface{}{time.Now(), sql.NullString{String: "user1", Valid: true}, struct{ X, Y int }{1, 2}} {
+			if _, ok := sqldb.ConvertNamedVars([]interface{}{arg}); ok {
+				t.Errorf("`%T` should not be converted to named arguments", arg)
+			}
+		}
+		if namedVars, ok := sqldb.ConvertNamedVars([]interface{}{filter}); !ok || len(namedVars) != 2 {
+			t.Errorf("struct with db tags should be converted to named arguments, got `%v`", namedVars)
+		}
+	})
+}
+
 func TestTx(t *testing.T) {
 	tests.RunWithDB(t, func(t *testing.T) {
 		err := tests.DBEngine.Tx(func(tx *sqldb.SqlDB) error {
@@ -5,6 +5,7 @@ import (
 	"database/sql/driver"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -41,6 +42,41 @@ func (stmt *Statement) AddClause(v clause.IClause) {
 	stmt.Clauses[name] = c
 }
 
+// 已有的 WHERE 条件含 OR 时合并为一组，使之后追加的条件约束所有分支，
+// 如 a OR b 追加 c 时生成 (a OR b) AND c
+func (stmt *Statement) groupWhere() {
+	c, ok := stmt.Clauses["WHERE"]
+	where, isWhere := c.Expression.(clause.Where)
+	if !ok || !isWhere || !hasOrCondition(where.Exprs) {
+		return
+	}
+	c.Expression = clause.Where{Exprs: []clause.Expression{clause.GroupConditions{Exprs: where.Exprs}}}
+	stmt.Clauses["WHERE"] = c
+}
+
+var orPattern = regexp.MustCompile(`(?i)\bOR\b`)
+
+// 条件之间以 OR 连接，或原生 SQL 条件中含有 OR
+func hasOrCondition(exprs []clause.Expression) bool {
+	for _, expr := range exprs {
+		switch v := expr.(type) {
+		case clause.OrConditions:
+			if len(v.Exprs) == 1 {
+				return true
+			}
+		case clause.Expr:
+			if orPattern.MatchString(v.SQL) {
+				return true
+			}
+		case clause.NamedExpr:
+			if orPattern.MatchString(v.SQL) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // 如果子句不存在则添加
 func (stmt *Statement) AddClauseIfNotExists(v clause.IClause) {
 	if _, ok := stmt.Clauses[v.Name()]; !ok {
@@ -55,6 +91,12 @@ func (stmt *Statement) BuildCondition(query interface{}, args ...interface{}) (c
 			argsLen := len(args)
 			if argsLen == 0 && sqlStr == "" {
 				return conditions, nil
+			} else if namedVars, ok := ConvertNamedVars(args); ok && strings.Contains(sqlStr, "@") {
+				expr := clause.NamedExpr{SQL: sqlStr, Vars: namedVars}
+				if err := expr.Check(); err != nil {
+					return nil, err
+				}
+				return []clause.Expression{expr}, nil
 			} else if argsLen == 0 || strings.Contains(sqlStr, "@") {
 				return []clause.Expression{clause.Expr{SQL: sqlStr, Vars: args}}, nil
 			} else if argsLen > 0 && strings.Contains(sqlStr, "?") {
@@ -90,6 +132,50 @@ func (stmt *Statement) BuildCondition(query interface{}, args ...interface{}) (c
 	return conditions, nil
 }
 
+// 将命名参数统一转换为 sql.NamedArg，支持 sql.NamedArg、map[string]interface{} 及带 db 标签的 struct，
+// 参数中不含命名参数时 ok 为 false
+func ConvertNamedVars(args []interface{}) (namedVars []interface{}, ok bool) {
+	for _, arg := range args {
+		switch v := arg.(type) {
+		case sql.NamedArg:
+			namedVars = append(namedVars, v)
+		case map[string]interface{}:
+			for name, value := range v {
+				namedVars = append(namedVars, sql.Named(name, value))
+			}
+		case driver.Valuer:
+			return nil, false
+		default:
+			rv := reflect.Indirect(reflect.ValueOf(arg))
+			if rv.Kind() != reflect.Struct || !hasDBTag(rv.Type()) {
+				return nil, false
+			}
+			for name, value := range mapper.FieldMap(rv) {
+				namedVars = append(namedVars, sql.Named(name, reflect.Indirect(value).Interface()))
+			}
+		}
+	}
+	return namedVars, len(namedVars) > 0
+}
+
+// struct(含嵌入的 struct)中是否有字段声明了 db 标签
+func hasDBTag(typ reflect.Type) bool {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if _, ok := field.Tag.Lookup("db"); ok {
+			return true
+		}
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && fieldType.Kind() == reflect.Struct && hasDBTag(fieldType) {
+			return true
+		}
+	}
+	return false
+}
+
 // 构建sql
 func (stmt *Statement) Build(clauses ...string) {
 	var firstClauseWritten bool
@@ -192,12 +278,8 @@ func (stmt *Statement) AddSQLVar(writer clause.Writer, vars ...interface{}) {
 		case sql.NamedArg:
 			if len(v.Name) > 0 {
 				stmt.NamedVars = append(stmt.NamedVars, v)
-				writer.WriteByte('@')
-				writer.WriteString(v.Name)
-			} else {
-				stmt.SQLVars = append(stmt.SQLVars, v.Value)
-				stmt.Dialector.BindVarTo(writer, len(stmt.SQLVars), v.Value)
 			}
+			stmt.AddSQLVar(writer, v.Value)
 		case clause.Column, clause.Table:
 			stmt.QuoteTo(writer, v)
 		case clause.Expr, *clause.Expr:
@@ -209,7 +291,7 @@ func (stmt *Statement) AddSQLVar(writer clause.Writer, vars ...interface{}) {
 			}
 			writer.WriteString(expr.SQL)
 			stmt.SQLVars = append(stmt.SQLVars, expr.Vars...)
-		case driver.Valuer:
+		case driver.Valuer, []byte:
 			stmt.SQLVars = append(stmt.SQLVars, v)
 			stmt.Dialector.BindVarTo(writer, len(stmt.SQLVars), v)
 		case []interface{}:
@@ -254,3 +336,63 @@ func (stmt *Statement) ReInit() {
 		delete(stmt.Clauses, k)
 	}
 }
+
+// 复制语句，子句中的切片会被复制，复制后的语句与原语句可以独立修改和构建
+func (stmt *Statement) Clone() *Statement {
+	newStmt := &Statement{
+		Dialector: stmt.Dialector,
+		Tables:    append([]clause.Table(nil), stmt.Tables...),
+		Clauses:   make(map[string]clause.Clause, len(stmt.Clauses)),
+		SQLVars:   append([]interface{}(nil), stmt.SQLVars...),
+		NamedVars: append([]sql.NamedArg(nil), stmt.NamedVars...),
+		Hint:      stmt.Hint,
+	}
+	newStmt.SQL.WriteString(stmt.SQL.String())
+
+	for name, c := range stmt.Clauses {
+		c.BeforeExpressions = append([]clause.Expression(nil), c.BeforeExpressions...)
+		c.AfterNameExpressions = append([]clause.Expression(nil), c.AfterNameExpressions...)
+		c.AfterExpressions = append([]clause.Expression(nil), c.AfterExpressions...)
+		c.Expression = cloneExpression(c.Expression)
+		newStmt.Clauses[name] = c
+	}
+
+	return newStmt
+}
+
+func cloneExpression(expr clause.Expression) clause.Expression {
+	switch v := expr.(type) {
+	case clause.Where:
+		v.Exprs = append([]clause.Expression(nil), v.Exprs...)
+		return v
+	case clause.Select:
+		v.Columns = append([]clause.Column(nil), v.Columns...)
+		v.Expressions = append([]clause.Expression(nil), v.Expressions...)
+		return v
+	case clause.From:
+		v.Tables = append([]clause.Table(nil), v.Tables...)
+		v.Joins = append([]clause.Join(nil), v.Joins...)
+		return v
+	case clause.GroupBy:
+		v.Columns = append([]clause.Column(nil), v.Columns...)
+		v.Having = append([]clause.Expression(nil), v.Having...)
+		return v
+	case clause.OrderBy:
+		v.Columns = append([]clause.OrderByColumn(nil), v.Columns...)
+		return v
+	case clause.Set:
+		v.Assignments = append([]clause.Assignment(nil), v.Assignments...)
+		return v
+	case clause.Values:
+		v.Columns = append([]clause.Column(nil), v.Columns...)
+		v.Values = append([][]interface{}(nil), v.Values...)
+		return v
+	case clause.Returning:
+		v.Columns = append([]clause.Column(nil), v.Columns...)
+		return v
+	case clause.For:
+		v.Locks = append([]clause.Lock(nil), v.Locks...)
+		return v
+	}
+	return expr
+}
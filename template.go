@@ -0,0 +1,138 @@
+package sqldb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/binwen/sqldb/dialects"
+)
+
+// 模板中可直接输出到 SQL 的函数，其余输出均作为参数绑定
+var templateOutputFuncs = map[string]bool{"bind": true, "in": true, "ident": true, "raw": true}
+
+type sqlTemplate struct {
+	dialector dialects.Dialector
+	vars      []interface{}
+}
+
+// 绑定参数，输出占位符
+func (t *sqlTemplate) bind(value interface{}) string {
+	t.vars = append(t.vars, value)
+	return "?"
+}
+
+// 将 slice 展开为 (?,?,?)，空 slice 输出 (NULL)
+func (t *sqlTemplate) in(value interface{}) string {
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array || rv.Type().Elem().Kind() == reflect.Uint8 {
+		return "(" + t.bind(value) + ")"
+	}
+	if rv.Len() == 0 {
+		return "(NULL)"
+	}
+
+	placeholders := make([]string, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		placeholders[i] = t.bind(rv.Index(i).Interface())
+	}
+	return "(" + strings.Join(placeholders, ",") + ")"
+}
+
+// 使用当前方言引用标识符，支持 table.column 形式
+func (t *sqlTemplate) ident(name string) (string, error) {
+	var builder strings.Builder
+	for idx, part := range strings.Split(name, ".") {
+		if part == "" || strings.ContainsAny(part, "`\"'[]\x00") {
+			return "", fmt.Errorf("invalid identifier `%s`", name)
+		}
+		if idx > 0 {
+			builder.WriteByte('.')
+		}
+		t.dialector.QuoteTo(&builder, part)
+	}
+	return builder.String(), nil
+}
+
+// 原样输出可信的 SQL 片段
+func (t *sqlTemplate) raw(fragment interface{}) string {
+	return fmt.Sprint(fragment)
+}
+
+func (t *sqlTemplate) render(text string, data interface{}) (string, error) {
+	tmpl, err := template.New("sql").Funcs(template.FuncMap{
+		"bind":  t.bind,
+		"in":    t.in,
+		"ident": t.ident,
+		"raw":   t.raw,
+	}).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	for _, item := range tmpl.Templates() {
+		if item.Tree != nil {
+			bindActions(item.Tree.Root)
+		}
+	}
+
+	var builder strings.Builder
+	if err := tmpl.Execute(&builder, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(builder.String()), nil
+}
+
+// 为所有直接输出的动作追加 bind，防止值被拼接到 SQL 中
+func bindActions(node parse.Node) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			bindActions(child)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) > 0 || len(n.Pipe.Cmds) == 0 {
+			return
+		}
+		last := n.Pipe.Cmds[len(n.Pipe.Cmds)-1]
+		if ident, ok := last.Args[0].(*parse.IdentifierNode); ok && templateOutputFuncs[ident.Ident] {
+			return
+		}
+		n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+			NodeType: parse.NodeCommand,
+			Pos:      n.Pos,
+			Args:     []parse.Node{parse.NewIdentifier("bind").SetPos(n.Pos)},
+		})
+	case *parse.IfNode:
+		bindActions(n.List)
+		bindActions(n.ElseList)
+	case *parse.RangeNode:
+		bindActions(n.List)
+		bindActions(n.ElseList)
+	case *parse.WithNode:
+		bindActions(n.List)
+		bindActions(n.ElseList)
+	}
+}
+
+// 使用 text/template 渲染 SQL，模板中输出的值均作为参数绑定，如:
+//
+//	db.Template(`select * from {{ ident .Table }} where age > {{ .Age }}
+//		{{ if .Ids }} and id in {{ in .Ids }}{{ end }}`, data)
+//
+// 模板函数: bind 绑定参数，in 展开列表，ident 引用标识符，raw 原样输出可信的 SQL 片段
+func (db *SqlDB) Template(text string, data interface{}) *RawSession {
+	return db.TemplateContext(context.Background(), text, data)
+}
+
+func (db *SqlDB) TemplateContext(ctx context.Context, text string, data interface{}) *RawSession {
+	tmpl := &sqlTemplate{dialector: db.engine.Dialector}
+	query, err := tmpl.render(text, data)
+	return &RawSession{ctx: ctx, db: db, query: query, vars: tmpl.vars, err: err}
+}
@@ -0,0 +1,72 @@
+package sqldb_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/binwen/sqldb/tests"
+)
+
+const userTemplate = `
+select id from {{ ident .Table }}
+where 1 = 1
+{{- if .Name }} and username = {{ .Name }}{{ end }}
+{{- with .Ids }} and id in {{ in . }}{{ end }}
+{{- range $age := .Ages }} and age <> {{ $age }}{{ end }}
+order by {{ ident "auth_user.id" }} {{ raw .Order }}`
+
+func TestTemplate(t *testing.T) {
+	tests.RunWithDB(t, func(t *testing.T) {
+		tests.InsertAuthUserWithId(1, 2, 3, 4)
+		if _, err := tests.DBEngine.Exec("update auth_user set age = 20 where id = 4"); err != nil {
+			t.Fatal(err)
+		}
+
+		type filter struct {
+			Table string
+			Name  string
+			Ids   []int
+			Ages  []int
+			Order string
+		}
+
+		var ids []int
+		if err := tests.DBEngine.Template(userTemplate, filter{Table: "auth_user", Order: "desc"}).Fetch(&ids); err != nil {
+			t.Error(err)
+		} else if len(ids) != 4 || ids[0] != 4 {
+			t.Errorf("ids should be `[4 3 2 1]`, got `%v`", ids)
+		}
+
+		ids = nil
+		if err := tests.DBEngine.Template(userTemplate, filter{
+			Table: "auth_user", Ids: []int{1, 2, 4}, Ages: []int{20, 30},
+		}).Fetch(&ids); err != nil {
+			t.Error(err)
+		} else if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+			t.Errorf("ids should be `[1 2]`, got `%v`", ids)
+		}
+
+		ids = nil
+		if err := tests.DBEngine.Template(userTemplate, filter{Table: "auth_user", Name: "user1' or '1' = '1"}).Fetch(&ids); err != nil {
+			t.Error(err)
+		} else if len(ids) != 0 {
+			t.Errorf("value output should be bound as argument, got `%v`", ids)
+		}
+
+		ids = nil
+		if err := tests.DBEngine.Template(
+			`select id from auth_user where id in {{ in .Ids }}`, map[string]interface{}{"Ids": []int{}},
+		).Fetch(&ids); err != nil {
+			t.Error(err)
+		} else if len(ids) != 0 {
+			t.Errorf("empty in list should match nothing, got `%v`", ids)
+		}
+
+		if err := tests.DBEngine.Template(userTemplate, filter{Table: "auth_user` where 1 = 1 --"}).Fetch(&ids); err == nil || !strings.Contains(err.Error(), "invalid identifier") {
+			t.Errorf("invalid identifier should return error, got `%v`", err)
+		}
+		if _, err := tests.DBEngine.Template(`update auth_user set age = {{ .Age `, nil).Exec(); err == nil {
+			t.Error("invalid template should return error")
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"testing"
@@ -123,6 +124,18 @@ func RunWithDB(t *testing.T, test func(t *testing.T)) {
 	test(t)
 }
 
+// 执行两次建表(检查可重复执行)并清空表，用于 queue、outbox 等自行建表的组件
+func MigrateTable(t *testing.T, table string, migrate func(ctx context.Context) error) {
+	for i := 0; i < 2; i++ {
+		if err := migrate(context.Background()); err != nil {
+			t.Fatalf("migrate %s error:%s", table, err)
+		}
+	}
+	if _, err := DBEngine.Exec(fmt.Sprintf("DELETE FROM %s", table)); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func InsertAuthUserWithId(ids ...int) {
 	for _, id := range ids {
 		_, err = DBEngine.Raw(
@@ -1,9 +1,14 @@
 package sqldb
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
 	"reflect"
 	"regexp"
 	"unicode"
+
+	"github.com/jmoiron/sqlx"
 )
 
 var (
@@ -62,3 +67,29 @@ func IsIntZero(value reflect.Value) bool {
 	}
 	return false
 }
+
+// 携带错误的 *sqlx.Row，Scan、Err 返回 err；sqlx.Row 的字段不可导出，
+// 因此通过连接总是失败的 sql.DB 构造，不会访问数据库
+func errRow(err error) *sqlx.Row {
+	db := sqlx.NewDb(sql.OpenDB(errConnector{err: err}), "")
+	defer db.Close()
+	return db.QueryRowx("")
+}
+
+type errConnector struct {
+	err error
+}
+
+func (c errConnector) Connect(context.Context) (driver.Conn, error) {
+	return nil, c.err
+}
+
+func (c errConnector) Driver() driver.Driver {
+	return errDriver(c)
+}
+
+type errDriver errConnector
+
+func (d errDriver) Open(string) (driver.Conn, error) {
+	return nil, d.err
+}